@@ -19,6 +19,9 @@ type Settings struct {
 	PageSize     int
 	ProtoVersion int
 	Timeout      string
+
+	// ObserveQueries - logs and traces every query and batch with the request id and trace of the query context
+	ObserveQueries bool
 }
 
 // New creates a gocql.Session from a cassandra.Settings
@@ -62,6 +65,13 @@ func New(settings Settings) (*gocql.Session, error) {
 
 	cluster.RetryPolicy = &gocql.SimpleRetryPolicy{NumRetries: settings.Retry}
 
+	// the query context request id and trace are used by the observers
+	if settings.ObserveQueries {
+		observer := newQueryObserver()
+		cluster.QueryObserver = observer
+		cluster.BatchObserver = observer
+	}
+
 	//TokenAwarePolicy
 	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.HostPoolHostPolicy(hostpool.New(nil)))
 
@@ -0,0 +1,37 @@
+package cassandra
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
+
+const healthQuery = "SELECT now() FROM system.local"
+
+// HealthChecker - checks a session created by New (satisfies the rip.HealthChecker interface)
+type HealthChecker struct {
+	session *gocql.Session
+}
+
+// NewHealthChecker - creates a new health checker for the session
+func NewHealthChecker(session *gocql.Session) *HealthChecker {
+
+	return &HealthChecker{
+		session: session,
+	}
+}
+
+// Name - returns the component name
+func (hc *HealthChecker) Name() string {
+	return "cassandra"
+}
+
+// Check - runs a lightweight query
+func (hc *HealthChecker) Check(ctx context.Context) error {
+
+	if hc.session.Closed() {
+		return gocql.ErrSessionClosed
+	}
+
+	return hc.session.Query(healthQuery).WithContext(ctx).Exec()
+}
@@ -0,0 +1,92 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// IdempotencyStore - stores the encoded idempotent responses (rip.IdempotencyStore) in a table created as:
+//
+//	CREATE TABLE idempotency (
+//	    key text PRIMARY KEY,
+//	    completed boolean,
+//	    response blob
+//	);
+//
+// the reservations and completions use lightweight transactions, so they are safe among many instances
+type IdempotencyStore struct {
+	session     *gocql.Session
+	beginQuery  string
+	finishQuery string
+	deleteQuery string
+}
+
+// NewIdempotencyStore - creates a new store for the session created by New
+func NewIdempotencyStore(session *gocql.Session, table string) *IdempotencyStore {
+
+	return &IdempotencyStore{
+		session:     session,
+		beginQuery:  "INSERT INTO " + table + " (key, completed) VALUES (?, false) IF NOT EXISTS USING TTL ?",
+		finishQuery: "UPDATE " + table + " USING TTL ? SET completed = true, response = ? WHERE key = ? IF completed = false",
+		deleteQuery: "DELETE FROM " + table + " WHERE key = ? IF completed = false",
+	}
+}
+
+// ttlSeconds - converts the duration to a TTL of at least one second
+func ttlSeconds(d time.Duration) int {
+
+	if seconds := int(d / time.Second); seconds > 0 {
+		return seconds
+	}
+
+	return 1
+}
+
+// Begin - reserves the key for lockTimeout returning true, or returns the completed response or nil if another request holds it
+func (s *IdempotencyStore) Begin(ctx context.Context, key string, lockTimeout time.Duration) ([]byte, bool, error) {
+
+	existing := map[string]interface{}{}
+
+	applied, err := s.session.Query(s.beginQuery, key, ttlSeconds(lockTimeout)).WithContext(ctx).MapScanCAS(existing)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if applied {
+		return nil, true, nil
+	}
+
+	if completed, _ := existing["completed"].(bool); !completed {
+		return nil, false, nil
+	}
+
+	response, _ := existing["response"].([]byte)
+
+	return response, false, nil
+}
+
+// Complete - stores the response of the reserved key for ttl, failing if the reservation expired or was completed
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+
+	applied, err := s.session.Query(s.finishQuery, ttlSeconds(ttl), response, key).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	if !applied {
+		return errors.New("idempotency key reservation lost")
+	}
+
+	return nil
+}
+
+// Release - removes the reservation so the request can be retried
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+
+	_, err := s.session.Query(s.deleteQuery, key).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+
+	return err
+}
@@ -0,0 +1,86 @@
+package cassandra
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+	"github.com/rs/zerolog"
+	"github.com/uol/gobol"
+	"github.com/uol/gobol/tracing"
+	"github.com/uol/logh"
+)
+
+// queryObserver - logs and traces the queries and batches executed by the sessions created with New
+type queryObserver struct {
+	logger *logh.ContextualLogger
+}
+
+// newQueryObserver - creates a new query logger
+func newQueryObserver() *queryObserver {
+
+	return &queryObserver{
+		logger: logh.CreateContextualLogger("pkg", "cassandra"),
+	}
+}
+
+// event - returns the error event for failed queries or the debug event for the others, including the request id found in the context
+func (ql *queryObserver) event(ctx context.Context, err error) *zerolog.Event {
+
+	var ev *zerolog.Event
+	if err != nil {
+		if logh.ErrorEnabled {
+			ev = ql.logger.Error().Err(err)
+		}
+	} else if logh.DebugEnabled {
+		ev = ql.logger.Debug()
+	}
+
+	return gobol.LogWithRequestID(ctx, ev)
+}
+
+// ObserveQuery - implements the gocql.QueryObserver interface
+func (ql *queryObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+
+	_, span := tracing.StartAt(ctx, "cassandra.query", tracing.KindClient, q.Start)
+	span.SetAttribute("db.system", "cassandra")
+	span.SetAttribute("db.keyspace", q.Keyspace)
+	span.SetAttribute("db.statement", q.Statement)
+	span.SetAttribute("attempt", q.Attempt)
+	if q.Host != nil {
+		span.SetAttribute("net.peer", q.Host.ConnectAddress().String())
+	}
+	span.SetError(q.Err)
+	span.FinishAt(q.End)
+
+	ev := ql.event(ctx, q.Err)
+	if ev == nil {
+		return
+	}
+
+	ev.Str("keyspace", q.Keyspace).
+		Str("statement", q.Statement).
+		Int("attempt", q.Attempt).
+		Dur("duration", q.End.Sub(q.Start)).
+		Msg("query executed")
+}
+
+// ObserveBatch - implements the gocql.BatchObserver interface
+func (ql *queryObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+
+	_, span := tracing.StartAt(ctx, "cassandra.batch", tracing.KindClient, b.Start)
+	span.SetAttribute("db.system", "cassandra")
+	span.SetAttribute("db.keyspace", b.Keyspace)
+	span.SetAttribute("db.statements", len(b.Statements))
+	span.SetError(b.Err)
+	span.FinishAt(b.End)
+
+	ev := ql.event(ctx, b.Err)
+	if ev == nil {
+		return
+	}
+
+	ev.Str("keyspace", b.Keyspace).
+		Strs("statements", b.Statements).
+		Dur("duration", b.End.Sub(b.Start)).
+		Msg("batch executed")
+}
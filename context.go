@@ -0,0 +1,39 @@
+package gobol
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+type contextKeyType int
+
+const requestIDKey contextKeyType = 1
+
+// ContextWithRequestID - returns a copy of the context carrying the request id
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext - returns the request id stored in the context or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// LogWithRequestID - adds the request id found in the context to the log event (nil when the level is disabled)
+func LogWithRequestID(ctx context.Context, ev *zerolog.Event) *zerolog.Event {
+
+	if ev == nil {
+		return nil
+	}
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		ev = ev.Str("request_id", requestID)
+	}
+
+	return ev
+}
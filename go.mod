@@ -10,7 +10,7 @@ require (
 	github.com/json-iterator/go v1.1.10
 	github.com/julienschmidt/httprouter v1.2.0
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
-	github.com/modern-go/reflect2 v1.0.1 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pborman/uuid v0.0.0-20180906182336-adf5a7427709 // indirect
 	github.com/rs/zerolog v1.18.0
 	github.com/stretchr/testify v1.6.1
@@ -19,6 +19,7 @@ require (
 	github.com/uol/logh v1.0.1
 	github.com/uol/restrictedhttpclient v1.0.0
 	github.com/uol/serializer v1.3.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.3.0
 )
@@ -2,9 +2,11 @@ package loader
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	yaml "gopkg.in/yaml.v2"
@@ -51,3 +53,18 @@ func ConfToml(path string, settings interface{}) error {
 
 	return err
 }
+
+// ConfFile - loads the configuration choosing the format by the file extension (json, yaml, yml or toml)
+func ConfFile(path string, settings interface{}) error {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfJson(path, settings)
+	case ".yaml", ".yml":
+		return ConfYaml(path, settings)
+	case ".toml":
+		return ConfToml(path, settings)
+	default:
+		return fmt.Errorf("unsupported configuration file extension: %s", path)
+	}
+}
@@ -0,0 +1,166 @@
+package rip
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/uol/logh"
+)
+
+const (
+	headerUserAgent = "User-Agent"
+	accessLogMsg    = "access"
+)
+
+// AccessLogConfiguration - configures the access log middleware
+type AccessLogConfiguration struct {
+
+	// SampleRate - the fraction (0 to 1) of successful requests logged, zero or one logs all requests (errors are always logged)
+	SampleRate float64
+
+	// TrustedProxies - IPs or CIDRs allowed to set the X-Forwarded-For header
+	TrustedProxies []string
+
+	// ExcludedPaths - paths not logged, a trailing "*" matches by prefix (health endpoints, for example)
+	ExcludedPaths []string
+}
+
+// AccessLogHandler - writes one log event per request
+type AccessLogHandler struct {
+	next          http.Handler
+	sampleRate    float64
+	proxies       *proxyTrust
+	excludedPaths map[string]struct{}
+	excludedPrefs []string
+	logger        *logh.ContextualLogger
+}
+
+// NewAccessLogMiddleware - creates a new instance of AccessLogHandler
+func NewAccessLogMiddleware(next http.Handler, configuration *AccessLogConfiguration) (*AccessLogHandler, error) {
+
+	if configuration == nil {
+		configuration = &AccessLogConfiguration{}
+	}
+
+	proxies, err := newProxyTrust(configuration.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &AccessLogHandler{
+		next:          next,
+		sampleRate:    configuration.SampleRate,
+		proxies:       proxies,
+		excludedPaths: map[string]struct{}{},
+		logger:        logh.CreateContextualLogger("pkg", "rip", "type", "access"),
+	}
+
+	for _, path := range configuration.ExcludedPaths {
+		if strings.HasSuffix(path, "*") {
+			h.excludedPrefs = append(h.excludedPrefs, strings.TrimSuffix(path, "*"))
+		} else {
+			h.excludedPaths[path] = struct{}{}
+		}
+	}
+
+	return h, nil
+}
+
+// excluded - checks if the path must not be logged
+func (h *AccessLogHandler) excluded(path string) bool {
+
+	if _, ok := h.excludedPaths[path]; ok {
+		return true
+	}
+
+	for _, prefix := range h.excludedPrefs {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sampled - checks if a successful request must be logged
+func (h *AccessLogHandler) sampled() bool {
+
+	if h.sampleRate <= 0 || h.sampleRate >= 1 {
+		return true
+	}
+
+	return rand.Float64() < h.sampleRate
+}
+
+// event - returns the log event according to the status class
+func (h *AccessLogHandler) event(status int) *zerolog.Event {
+
+	switch {
+	case status >= http.StatusInternalServerError:
+		if logh.ErrorEnabled {
+			return h.logger.Error()
+		}
+	case status >= http.StatusBadRequest:
+		if logh.WarnEnabled {
+			return h.logger.Warn()
+		}
+	default:
+		if logh.InfoEnabled && h.sampled() {
+			return h.logger.Info()
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *AccessLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if h.excluded(r.URL.Path) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+
+	r, holder := withRouteHolder(r)
+
+	logResponseWriter := &LogResponseWriter{
+		ResponseWriter: w,
+	}
+
+	h.next.ServeHTTP(logResponseWriter, r)
+
+	status := logResponseWriter.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	ev := h.event(status)
+	if ev == nil {
+		return
+	}
+
+	requestID := RequestID(r)
+	if requestID == "" {
+		requestID = w.Header().Get(headerRequestID)
+	}
+
+	route := r.URL.Path
+	if holder.route != nil {
+		route = holder.route.Path
+	}
+
+	ev.Str("method", r.Method).
+		Str("route", route).
+		Int("status", status).
+		Int("bytes", logResponseWriter.size).
+		Dur("duration", time.Since(start)).
+		Str("remote", h.proxies.clientIP(r)).
+		Str("user_agent", r.Header.Get(headerUserAgent)).
+		Str("request_id", requestID).
+		Msg(accessLogMsg)
+}
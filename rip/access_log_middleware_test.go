@@ -0,0 +1,131 @@
+package rip
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+// captureLogs - returns the JSON log events written while running the function
+func captureLogs(t *testing.T, run func()) []map[string]interface{} {
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	logh.ConfigureGlobalLogger(logh.INFO, logh.JSON)
+
+	events := make(chan []map[string]interface{})
+	go func() {
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := map[string]interface{}{}
+			if json.Unmarshal(scanner.Bytes(), &line) == nil {
+				lines = append(lines, line)
+			}
+		}
+		events <- lines
+	}()
+
+	defer func() {
+		os.Stdout = stdout
+		logh.ConfigureGlobalLogger(logh.INFO, logh.JSON)
+	}()
+
+	run()
+
+	writer.Close()
+
+	return <-events
+}
+
+func TestAccessLog(t *testing.T) {
+
+	router := NewRouter()
+	router.GET("/documents/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		if ps.ByName("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		Success(w, http.StatusOK, []byte("doc"))
+		return nil
+	})
+
+	h, err := NewAccessLogMiddleware(router, &AccessLogConfiguration{
+		TrustedProxies: []string{"10.0.0.0/8"},
+		ExcludedPaths:  []string{"/health/*", "/metrics"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	serve := func(path string) {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set(headerXForwardedFor, "1.1.1.1")
+		r.Header.Set(headerUserAgent, "test")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	events := captureLogs(t, func() {
+		serve("/documents/1")
+		serve("/documents/missing")
+		serve("/health/ready")
+		serve("/metrics")
+	})
+
+	var access []map[string]interface{}
+	for _, event := range events {
+		if event["message"] == accessLogMsg {
+			access = append(access, event)
+		}
+	}
+
+	if !assert.Len(t, access, 2, "the excluded paths must not be logged") {
+		return
+	}
+
+	assert.Equal(t, "info", access[0]["level"])
+	assert.Equal(t, "/documents/:id", access[0]["route"])
+	assert.Equal(t, float64(http.StatusOK), access[0]["status"])
+	assert.Equal(t, float64(3), access[0]["bytes"])
+	assert.Equal(t, "1.1.1.1", access[0]["remote"])
+	assert.Equal(t, "test", access[0]["user_agent"])
+
+	assert.Equal(t, "warn", access[1]["level"], "client errors must be logged as warnings")
+	assert.Equal(t, float64(http.StatusNotFound), access[1]["status"])
+}
+
+func TestAccessLogSampling(t *testing.T) {
+
+	h, err := NewAccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &AccessLogConfiguration{SampleRate: 0.5})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, rate := range []float64{0, 1} {
+		h.sampleRate = rate
+		assert.True(t, h.sampled(), "zero and one log all requests")
+	}
+
+	h.sampleRate = 0.000001
+	logged := 0
+	for i := 0; i < 1000; i++ {
+		if h.sampled() {
+			logged++
+		}
+	}
+	assert.Less(t, logged, 10)
+}
@@ -0,0 +1,142 @@
+package rip
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/gobol"
+)
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	gobolErrorType = reflect.TypeOf((*gobol.Error)(nil)).Elem()
+	validatorType  = reflect.TypeOf((*Validator)(nil)).Elem()
+)
+
+// StatusCoder - implemented by the responses of typed handlers choosing their status code
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// typedHandler - the reflected typed handler
+type typedHandler struct {
+	fn          reflect.Value
+	requestType reflect.Type
+}
+
+// Typed - adapts a function with the signature func(context.Context, *Req) (*Resp, gobol.Error) to a HandlerFunc,
+// the request is decoded from the JSON body and bound from the path and query parameters (the "path" and "query"
+// field tags, see Bind) then validated if it implements Validator; the response is rendered as JSON with the status code
+// 201 for POST, 200 for the other methods, 204 when nil or the one returned by StatusCoder
+func Typed(fn interface{}) HandlerFunc {
+
+	h, err := newTypedHandler(fn)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.serve
+}
+
+// newTypedHandler - checks the function signature
+func newTypedHandler(fn interface{}) (*typedHandler, error) {
+
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("typed handler must be a function, got %s", t)
+	}
+
+	if t.NumIn() != 2 || t.In(0) != contextType || t.In(1).Kind() != reflect.Ptr || t.In(1).Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("typed handler %s must receive (context.Context, *struct)", t)
+	}
+
+	if t.NumOut() != 2 || t.Out(0).Kind() != reflect.Ptr || t.Out(1) != gobolErrorType {
+		return nil, fmt.Errorf("typed handler %s must return (*T, gobol.Error)", t)
+	}
+
+	return &typedHandler{
+		fn:          v,
+		requestType: t.In(1).Elem(),
+	}, nil
+}
+
+// serve - implements the HandlerFunc
+func (h *typedHandler) serve(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	request := reflect.New(h.requestType)
+
+	if gerr := decodeBody(r, request.Interface()); gerr != nil {
+		return gerr
+	}
+
+	if gerr := Bind(r, ps, request.Interface()); gerr != nil {
+		return gerr
+	}
+
+	if request.Type().Implements(validatorType) {
+		if gerr := request.Interface().(Validator).Validate(); gerr != nil {
+			return gerr
+		}
+	}
+
+	out := h.fn.Call([]reflect.Value{reflect.ValueOf(r.Context()), request})
+
+	if !out[1].IsNil() {
+		return out[1].Interface().(gobol.Error)
+	}
+
+	if out[0].IsNil() {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	response := out[0].Interface()
+
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+
+	if coder, ok := response.(StatusCoder); ok {
+		status = coder.StatusCode()
+	}
+
+	SuccessJSON(w, status, response)
+
+	return nil
+}
+
+// decodeBody - decodes the JSON body when there is one
+func decodeBody(r *http.Request, v interface{}) gobol.Error {
+
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	defer r.Body.Close()
+
+	var reader io.Reader = r.Body
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return errUnmarshal("rip", "decodeBody", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	if err := json.NewDecoder(reader).Decode(v); err != nil && err != io.EOF {
+		return errUnmarshal("rip", "decodeBody", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+type typedRequest struct {
+	Collection string `json:"-" path:"collection"`
+	Limit      int    `json:"-" query:"limit"`
+	Name       string `json:"name"`
+}
+
+func (t *typedRequest) Validate() gobol.Error {
+
+	if t.Name == "" && t.Limit == 0 {
+		return errBasic("rip", "Validate", "name or limit is required", http.StatusBadRequest, errors.New("invalid request"))
+	}
+
+	return nil
+}
+
+type typedResponse struct {
+	Collection string `json:"collection"`
+	Limit      int    `json:"limit"`
+	Name       string `json:"name"`
+}
+
+type acceptedResponse struct{}
+
+func (acceptedResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+func TestTyped(t *testing.T) {
+
+	router := NewRouter()
+
+	handler := func(ctx context.Context, req *typedRequest) (*typedResponse, gobol.Error) {
+
+		if req.Name == "missing" {
+			return nil, errBasic("rip", "handler", "not found", http.StatusNotFound, errors.New("not found"))
+		}
+
+		if req.Name == "empty" {
+			return nil, nil
+		}
+
+		return &typedResponse{Collection: req.Collection, Limit: req.Limit, Name: req.Name}, nil
+	}
+
+	router.GET("/collections/:collection", Typed(handler))
+	router.POST("/collections/:collection", Typed(handler))
+	router.PUT("/collections/:collection", Typed(func(ctx context.Context, req *typedRequest) (*acceptedResponse, gobol.Error) {
+		return &acceptedResponse{}, nil
+	}))
+
+	w := serveRouter(router, http.MethodGet, "/collections/news?limit=10")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"collection":"news","limit":10,"name":""}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/collections/news", strings.NewReader(`{"name":"sports"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"collection":"news","limit":0,"name":"sports"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/collections/news", strings.NewReader(`{"name":"empty"}`)))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/collections/news", strings.NewReader(`{"name":"missing"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/collections/news", strings.NewReader(`{"name":"x"}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = serveRouter(router, http.MethodGet, "/collections/news")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "validation must fail")
+
+	w = serveRouter(router, http.MethodGet, "/collections/news?limit=ten")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "binding must fail")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/collections/news", strings.NewReader(`{"name":`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "decoding must fail")
+}
+
+func TestTypedInvalidSignature(t *testing.T) {
+
+	assert.Panics(t, func() { Typed(func(req *typedRequest) (*typedResponse, gobol.Error) { return nil, nil }) })
+	assert.Panics(t, func() {
+		Typed(func(ctx context.Context, req typedRequest) (*typedResponse, gobol.Error) { return nil, nil })
+	})
+	assert.Panics(t, func() {
+		Typed(func(ctx context.Context, req *typedRequest) (*typedResponse, error) { return nil, nil })
+	})
+	assert.Panics(t, func() { Typed("handler") })
+}
@@ -0,0 +1,313 @@
+package rip
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+const (
+	defaultAdminPrefix = "/admin"
+	redactedValue      = "***"
+)
+
+var (
+	startTime = time.Now()
+
+	// logLevelMutex - serializes the log level changes
+	logLevelMutex sync.Mutex
+
+	defaultRedactedFields = []string{"password", "secret", "token", "key", "credential"}
+
+	logLevels = map[logh.Level]zerolog.Level{
+		logh.DEBUG:  zerolog.DebugLevel,
+		logh.INFO:   zerolog.InfoLevel,
+		logh.WARN:   zerolog.WarnLevel,
+		logh.ERROR:  zerolog.ErrorLevel,
+		logh.FATAL:  zerolog.FatalLevel,
+		logh.PANIC:  zerolog.PanicLevel,
+		logh.NONE:   zerolog.NoLevel,
+		logh.SILENT: zerolog.Disabled,
+	}
+)
+
+// BuildInfo - the application build information
+type BuildInfo struct {
+	Version   string `json:"version,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Module    string `json:"module,omitempty"`
+}
+
+// AdminConfiguration - configures the admin endpoints
+type AdminConfiguration struct {
+
+	// Prefix - the admin routes prefix ("/admin" by default)
+	Prefix string
+
+	// EnablePprof - exposes the pprof endpoints
+	EnablePprof bool
+
+	// Roles - the principal must have one of these roles (any authenticated principal when empty)
+	Roles []string
+
+	// Build - the build information, usually set by ldflags
+	Build BuildInfo
+
+	// Config - the effective configuration, dumped with the secrets redacted
+	Config interface{}
+
+	// RedactedFields - field names redacted besides password, secret, token, key and credential (case insensitive, partial match)
+	RedactedFields []string
+}
+
+// logLevelPayload - the log level change request
+type logLevelPayload struct {
+	Level logh.Level `json:"level"`
+}
+
+// Validate - implements the Validator interface
+func (p *logLevelPayload) Validate() gobol.Error {
+
+	if _, ok := logLevels[p.Level]; !ok {
+		return errBasic("rip", "Validate", "invalid log level", http.StatusBadRequest, errors.New("invalid log level: "+string(p.Level)))
+	}
+
+	return nil
+}
+
+// admin - the admin endpoints
+type admin struct {
+	configuration  *AdminConfiguration
+	redactedFields []string
+	logger         *logh.ContextualLogger
+}
+
+// MountAdmin - registers the admin endpoints on the router, guarded by the authenticators
+func MountAdmin(router *Router, configuration *AdminConfiguration, authenticators ...Authenticator) error {
+
+	if configuration == nil {
+		return errors.New("null configuration")
+	}
+
+	if len(authenticators) == 0 {
+		return errors.New("the admin endpoints require at least one authenticator")
+	}
+
+	prefix := configuration.Prefix
+	if prefix == "" {
+		prefix = defaultAdminPrefix
+	}
+
+	a := &admin{
+		configuration: configuration,
+		logger:        logh.CreateContextualLogger("pkg", "rip", "type", "admin"),
+	}
+
+	for _, field := range append(defaultRedactedFields, configuration.RedactedFields...) {
+		a.redactedFields = append(a.redactedFields, strings.ToLower(field))
+	}
+
+	authorizer := NewAuthorizer(nil, nil)
+	rule := &AuthorizationRule{Roles: configuration.Roles}
+
+	group := router.Group(prefix, func(next http.Handler) http.Handler {
+		return NewAuthenticationMiddleware(next, authenticators...)
+	}, authorizer.Middleware())
+
+	group.GET("/runtime", a.runtimeStats, Name("admin.runtime"), Authorization(rule))
+	group.GET("/build", a.buildInfo, Name("admin.build"), Authorization(rule))
+	group.GET("/config", a.config, Name("admin.config"), Authorization(rule))
+	group.GET("/loglevel", a.logLevel, Name("admin.loglevel"), Authorization(rule))
+	group.PUT("/loglevel", a.setLogLevel, Name("admin.loglevel.set"), Authorization(rule))
+
+	if configuration.EnablePprof {
+		group.Handler(http.MethodGet, "/pprof/", http.HandlerFunc(pprof.Index), Authorization(rule))
+		group.GET("/pprof/:name", a.pprof, Authorization(rule))
+		group.Handler(http.MethodPost, "/pprof/symbol", http.HandlerFunc(pprof.Symbol), Authorization(rule))
+	}
+
+	return nil
+}
+
+// pprof - serves the pprof profiles
+func (a *admin) pprof(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	switch ps.ByName("name") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	default:
+		pprof.Handler(ps.ByName("name")).ServeHTTP(w, r)
+	}
+
+	return nil
+}
+
+// runtimeStats - returns the goroutines, memory and GC statistics
+func (a *admin) runtimeStats(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	m := runtime.MemStats{}
+	runtime.ReadMemStats(&m)
+
+	var lastGC string
+	if m.LastGC > 0 {
+		lastGC = time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339Nano)
+	}
+
+	SuccessJSON(w, http.StatusOK, map[string]interface{}{
+		"uptime":       time.Since(startTime).String(),
+		"goroutines":   runtime.NumGoroutine(),
+		"cpus":         runtime.NumCPU(),
+		"gomaxprocs":   runtime.GOMAXPROCS(0),
+		"heapAlloc":    m.HeapAlloc,
+		"heapSys":      m.HeapSys,
+		"heapObjects":  m.HeapObjects,
+		"totalAlloc":   m.TotalAlloc,
+		"sys":          m.Sys,
+		"numGC":        m.NumGC,
+		"lastGC":       lastGC,
+		"pauseTotalNs": m.PauseTotalNs,
+		"gcCPUPercent": m.GCCPUFraction * 100,
+	})
+
+	return nil
+}
+
+// buildInfo - returns the build information
+func (a *admin) buildInfo(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	info := a.configuration.Build
+	info.GoVersion = runtime.Version()
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Module = bi.Main.Path
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+	}
+
+	SuccessJSON(w, http.StatusOK, &info)
+
+	return nil
+}
+
+// config - returns the effective configuration with the secrets redacted
+func (a *admin) config(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	b, err := json.Marshal(a.configuration.Config)
+	if err != nil {
+		return errBasic("rip", "config", "error serializing the configuration", http.StatusInternalServerError, err)
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return errBasic("rip", "config", "error serializing the configuration", http.StatusInternalServerError, err)
+	}
+
+	SuccessJSON(w, http.StatusOK, a.redact(generic))
+
+	return nil
+}
+
+// redact - replaces the values of the sensitive fields
+func (a *admin) redact(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if a.sensitive(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = a.redact(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.redact(item)
+		}
+	}
+
+	return value
+}
+
+// sensitive - checks if the field must be redacted
+func (a *admin) sensitive(field string) bool {
+
+	field = strings.ToLower(field)
+	for _, redacted := range a.redactedFields {
+		if strings.Contains(field, redacted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logLevel - returns the current log level
+func (a *admin) logLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	current := zerolog.GlobalLevel()
+	for level, zlevel := range logLevels {
+		if zlevel == current {
+			SuccessJSON(w, http.StatusOK, &logLevelPayload{Level: level})
+			return nil
+		}
+	}
+
+	SuccessJSON(w, http.StatusOK, &logLevelPayload{Level: logh.Level(current.String())})
+
+	return nil
+}
+
+// setLogLevel - changes the global log level, the logh flags are plain booleans read without synchronization
+// by every logger, so the readers may see the old level for a while (a benign race reported by the race detector)
+func (a *admin) setLogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+	payload := logLevelPayload{}
+	if gerr := FromJSON(r, &payload); gerr != nil {
+		return gerr
+	}
+
+	logLevelMutex.Lock()
+
+	zerolog.SetGlobalLevel(logLevels[payload.Level])
+
+	logh.InfoEnabled = logh.Info() != nil
+	logh.DebugEnabled = logh.Debug() != nil
+	logh.WarnEnabled = logh.Warn() != nil
+	logh.ErrorEnabled = logh.Error() != nil
+	logh.PanicEnabled = logh.Panic() != nil
+	logh.FatalEnabled = logh.Fatal() != nil
+
+	logLevelMutex.Unlock()
+
+	if logh.WarnEnabled {
+		ev := a.logger.Warn().Str("level", string(payload.Level))
+		if principal := PrincipalFromContext(r.Context()); principal != nil {
+			ev = ev.Str("principal", principal.ID)
+		}
+		gobol.LogWithRequestID(r.Context(), ev).Msg("log level changed")
+	}
+
+	SuccessJSON(w, http.StatusOK, &payload)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/logh"
+)
+
+// newAdminTest - creates a router with the admin endpoints guarded by API keys
+func newAdminTest(t *testing.T, configuration *AdminConfiguration) func(method, path, key, body string) *httptest.ResponseRecorder {
+
+	authenticator, err := NewAPIKeyAuthenticator(&APIKeyConfiguration{Keys: []APIKey{
+		{Key: "admin", Principal: "ops", Roles: []string{"admin"}},
+		{Key: "user", Principal: "user"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	if err := MountAdmin(router, configuration, authenticator); err != nil {
+		t.Fatal(err)
+	}
+
+	return func(method, path, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(defaultAPIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func TestAdminAuthorization(t *testing.T) {
+
+	serve := newAdminTest(t, &AdminConfiguration{Roles: []string{"admin"}})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/runtime", "", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/admin/runtime", "user", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/admin/runtime", "admin", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/admin/pprof/cmdline", "admin", "").Code, "pprof is disabled by default")
+
+	assert.Error(t, MountAdmin(NewRouter(), &AdminConfiguration{}))
+}
+
+func TestAdminConfig(t *testing.T) {
+
+	serve := newAdminTest(t, &AdminConfiguration{
+		RedactedFields: []string{"dsn"},
+		Config: map[string]interface{}{
+			"address": ":8080",
+			"database": map[string]interface{}{
+				"user":     "app",
+				"password": "pass",
+				"dsn":      "app:pass@db",
+			},
+			"apiKeys": []string{"k1"},
+		},
+	})
+
+	w := serve(http.MethodGet, "/admin/config", "admin", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"address":":8080","database":{"user":"app","password":"***","dsn":"***"},"apiKeys":"***"}`, w.Body.String())
+}
+
+func TestAdminLogLevel(t *testing.T) {
+
+	previous := zerolog.GlobalLevel()
+	infoEnabled, debugEnabled := logh.InfoEnabled, logh.DebugEnabled
+
+	defer func() {
+		zerolog.SetGlobalLevel(previous)
+		logh.InfoEnabled, logh.DebugEnabled = infoEnabled, debugEnabled
+	}()
+
+	serve := newAdminTest(t, &AdminConfiguration{})
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/loglevel", "admin", `{"level":"verbose"}`).Code)
+
+	w := serve(http.MethodPut, "/admin/loglevel", "admin", `{"level":"warn"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+	assert.False(t, logh.InfoEnabled)
+
+	w = serve(http.MethodGet, "/admin/loglevel", "admin", "")
+	assert.JSONEq(t, `{"level":"warn"}`, w.Body.String())
+}
+
+func TestAdminPprof(t *testing.T) {
+
+	serve := newAdminTest(t, &AdminConfiguration{EnablePprof: true})
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/admin/pprof/", "admin", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/admin/pprof/cmdline", "admin", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/admin/pprof/goroutine?debug=1", "admin", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/admin/pprof/symbol", "admin", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/pprof/cmdline", "", "").Code)
+}
@@ -0,0 +1,215 @@
+package rip
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+	"github.com/uol/gobol/loader"
+	"github.com/uol/logh"
+)
+
+const defaultAPIKeyHeader = "X-API-Key"
+
+// APIKey - an API key and its principal, multiple keys of the same principal allow rotations
+type APIKey struct {
+	Key       string
+	Principal string
+	Roles     []string
+	Scopes    []string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Disabled  bool
+}
+
+// APIKeys - the API keys file format
+type APIKeys struct {
+	Keys []APIKey
+}
+
+// APIKeyConfiguration - configures the API key authenticator
+type APIKeyConfiguration struct {
+
+	// Header - the header carrying the key (X-API-Key by default)
+	Header string
+
+	// Keys - static keys
+	Keys []APIKey
+
+	// File - a json, yaml or toml file with the keys, loaded by the loader package
+	File string
+
+	// ReloadInterval - the interval between checks of the file modification (no reloads when zero)
+	ReloadInterval funks.Duration
+}
+
+// APIKeyAuthenticator - authenticates requests by API keys
+type APIKeyAuthenticator struct {
+	header         string
+	static         []APIKey
+	file           string
+	reloadInterval time.Duration
+	mutex          sync.RWMutex
+	keys           map[[sha256.Size]byte]*APIKey
+	lastCheck      time.Time
+	lastModified   time.Time
+	logger         *logh.ContextualLogger
+}
+
+// NewAPIKeyAuthenticator - creates a new API key authenticator
+func NewAPIKeyAuthenticator(configuration *APIKeyConfiguration) (*APIKeyAuthenticator, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	header := configuration.Header
+	if header == "" {
+		header = defaultAPIKeyHeader
+	}
+
+	a := &APIKeyAuthenticator{
+		header:         header,
+		static:         configuration.Keys,
+		file:           configuration.File,
+		reloadInterval: configuration.ReloadInterval.Duration,
+		logger:         logh.CreateContextualLogger("pkg", "rip", "type", "apikey"),
+	}
+
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Reload - reloads the keys from the configured file
+func (a *APIKeyAuthenticator) Reload() error {
+
+	keys := make([]APIKey, 0, len(a.static))
+	keys = append(keys, a.static...)
+
+	var modified time.Time
+
+	if a.file != "" {
+
+		info, err := os.Stat(a.file)
+		if err != nil {
+			return err
+		}
+
+		loaded := APIKeys{}
+		if err := loader.ConfFile(a.file, &loaded); err != nil {
+			return err
+		}
+
+		keys = append(keys, loaded.Keys...)
+		modified = info.ModTime()
+	}
+
+	indexed := make(map[[sha256.Size]byte]*APIKey, len(keys))
+	for i := range keys {
+		if keys[i].Key == "" {
+			return errors.New("empty API key")
+		}
+		indexed[sha256.Sum256([]byte(keys[i].Key))] = &keys[i]
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.keys = indexed
+	a.lastModified = modified
+	a.lastCheck = time.Now()
+
+	return nil
+}
+
+// reloadIfModified - reloads the file if it was modified since the last check
+func (a *APIKeyAuthenticator) reloadIfModified(now time.Time) {
+
+	if a.file == "" || a.reloadInterval <= 0 {
+		return
+	}
+
+	a.mutex.Lock()
+	if now.Sub(a.lastCheck) < a.reloadInterval {
+		a.mutex.Unlock()
+		return
+	}
+	a.lastCheck = now
+	lastModified := a.lastModified
+	a.mutex.Unlock()
+
+	info, err := os.Stat(a.file)
+	if err != nil || !info.ModTime().After(lastModified) {
+		return
+	}
+
+	if err := a.Reload(); err != nil {
+		if logh.ErrorEnabled {
+			a.logger.Error().Err(err).Msg("error reloading the API keys")
+		}
+	}
+}
+
+// lookup - finds the key comparing the hashes in constant time
+func (a *APIKeyAuthenticator) lookup(key string) *APIKey {
+
+	hash := sha256.Sum256([]byte(key))
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	var found *APIKey
+	for h, k := range a.keys {
+		if subtle.ConstantTimeCompare(h[:], hash[:]) == 1 {
+			found = k
+		}
+	}
+
+	return found
+}
+
+// Authenticate - implements the Authenticator interface
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, gobol.Error) {
+
+	key := r.Header.Get(a.header)
+	if key == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	a.reloadIfModified(now)
+
+	apiKey := a.lookup(key)
+	if apiKey == nil {
+		return nil, errUnauthorized("Authenticate", errors.New("invalid API key"))
+	}
+
+	if apiKey.Disabled {
+		return nil, errForbidden("Authenticate", errors.New("disabled API key"))
+	}
+
+	if (!apiKey.NotBefore.IsZero() && now.Before(apiKey.NotBefore)) || (!apiKey.NotAfter.IsZero() && now.After(apiKey.NotAfter)) {
+		return nil, errUnauthorized("Authenticate", errors.New("expired API key"))
+	}
+
+	return &Principal{
+		ID:     apiKey.Principal,
+		Method: "apikey",
+		Roles:  apiKey.Roles,
+		Scopes: apiKey.Scopes,
+	}, nil
+}
+
+// Challenge - implements the Authenticator interface
+func (a *APIKeyAuthenticator) Challenge() string {
+	return ""
+}
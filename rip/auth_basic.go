@@ -0,0 +1,120 @@
+package rip
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/uol/gobol"
+	"github.com/uol/gobol/loader"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicUser - a HTTP Basic user with its bcrypt password hash
+type BasicUser struct {
+	Name         string
+	PasswordHash string
+	Roles        []string
+	Scopes       []string
+}
+
+// BasicUsers - the users file format
+type BasicUsers struct {
+	Users []BasicUser
+}
+
+// BasicConfiguration - configures the HTTP Basic authenticator
+type BasicConfiguration struct {
+
+	// Realm - the realm sent on the challenge
+	Realm string
+
+	// Users - static users
+	Users []BasicUser
+
+	// File - a json, yaml or toml file with the users, loaded by the loader package
+	File string
+}
+
+// BasicAuthenticator - authenticates requests by HTTP Basic credentials checked against bcrypt hashes
+type BasicAuthenticator struct {
+	realm string
+	users map[string]*BasicUser
+	dummy []byte
+}
+
+// NewBasicAuthenticator - creates a new HTTP Basic authenticator
+func NewBasicAuthenticator(configuration *BasicConfiguration) (*BasicAuthenticator, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	users := make([]BasicUser, 0, len(configuration.Users))
+	users = append(users, configuration.Users...)
+
+	if configuration.File != "" {
+		loaded := BasicUsers{}
+		if err := loader.ConfFile(configuration.File, &loaded); err != nil {
+			return nil, err
+		}
+		users = append(users, loaded.Users...)
+	}
+
+	a := &BasicAuthenticator{
+		realm: configuration.Realm,
+		users: make(map[string]*BasicUser, len(users)),
+	}
+
+	for i := range users {
+		if _, err := bcrypt.Cost([]byte(users[i].PasswordHash)); err != nil {
+			return nil, errors.New("invalid bcrypt hash for user " + users[i].Name)
+		}
+		a.users[users[i].Name] = &users[i]
+	}
+
+	// used to spend the same time on unknown users
+	dummy, err := bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	a.dummy = dummy
+
+	return a, nil
+}
+
+// Authenticate - implements the Authenticator interface
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*Principal, gobol.Error) {
+
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+
+	user, found := a.users[name]
+	if !found {
+		bcrypt.CompareHashAndPassword(a.dummy, []byte(password))
+		return nil, errUnauthorized("Authenticate", errors.New("invalid user or password"))
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errUnauthorized("Authenticate", errors.New("invalid user or password"))
+	}
+
+	return &Principal{
+		ID:     user.Name,
+		Method: "basic",
+		Roles:  user.Roles,
+		Scopes: user.Scopes,
+	}, nil
+}
+
+// Challenge - implements the Authenticator interface
+func (a *BasicAuthenticator) Challenge() string {
+
+	if a.realm == "" {
+		return "Basic"
+	}
+
+	return "Basic realm=" + strconv.Quote(a.realm)
+}
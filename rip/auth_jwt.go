@@ -0,0 +1,420 @@
+package rip
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+	"github.com/uol/gobol/loader"
+	"github.com/uol/logh"
+)
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+
+	bearerPrefix = "Bearer "
+
+	defaultRolesClaim     = "roles"
+	defaultScopesClaim    = "scope"
+	defaultJWKSMinRefresh = time.Minute
+)
+
+// JWTConfiguration - configures the JWT bearer token authenticator
+type JWTConfiguration struct {
+
+	// Secret - the HS256 shared secret
+	Secret string
+
+	// JWKSFile - a JSON Web Key Set file with the RS256 public keys
+	JWKSFile string
+
+	// JWKSURL - a JSON Web Key Set URL with the RS256 public keys
+	JWKSURL string
+
+	// JWKSRefreshInterval - the interval between key set reloads (unknown key ids also trigger a reload, at most once per minute)
+	JWKSRefreshInterval funks.Duration
+
+	// Issuer - the required "iss" claim (not checked when empty)
+	Issuer string
+
+	// Audience - the required "aud" claim (not checked when empty)
+	Audience string
+
+	// Leeway - the clock skew tolerated on "exp" and "nbf"
+	Leeway funks.Duration
+
+	// RolesClaim - the claim with the roles ("roles" by default)
+	RolesClaim string
+
+	// ScopesClaim - the claim with the scopes, space separated or array ("scope" by default)
+	ScopesClaim string
+}
+
+// jwk - a JSON Web Key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwks - a JSON Web Key Set
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwtHeader - the JOSE header
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// JWTAuthenticator - authenticates requests by JWT bearer tokens signed with HS256 or RS256
+type JWTAuthenticator struct {
+	secret          []byte
+	jwksFile        string
+	jwksURL         string
+	refreshInterval time.Duration
+	issuer          string
+	audience        string
+	leeway          time.Duration
+	rolesClaim      string
+	scopesClaim     string
+	httpClient      *http.Client
+	mutex           sync.RWMutex
+	refreshMutex    sync.Mutex
+	keys            map[string]*rsa.PublicKey
+	lastRefresh     time.Time
+	lastAttempt     time.Time
+	logger          *logh.ContextualLogger
+}
+
+// NewJWTAuthenticator - creates a new JWT authenticator
+func NewJWTAuthenticator(configuration *JWTConfiguration) (*JWTAuthenticator, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	if configuration.Secret == "" && configuration.JWKSFile == "" && configuration.JWKSURL == "" {
+		return nil, errors.New("no secret or key set configured")
+	}
+
+	a := &JWTAuthenticator{
+		secret:          []byte(configuration.Secret),
+		jwksFile:        configuration.JWKSFile,
+		jwksURL:         configuration.JWKSURL,
+		refreshInterval: configuration.JWKSRefreshInterval.Duration,
+		issuer:          configuration.Issuer,
+		audience:        configuration.Audience,
+		leeway:          configuration.Leeway.Duration,
+		rolesClaim:      configuration.RolesClaim,
+		scopesClaim:     configuration.ScopesClaim,
+		httpClient:      funks.CreateHTTPClient(10*time.Second, false),
+		keys:            map[string]*rsa.PublicKey{},
+		logger:          logh.CreateContextualLogger("pkg", "rip", "type", "jwt"),
+	}
+
+	if a.rolesClaim == "" {
+		a.rolesClaim = defaultRolesClaim
+	}
+
+	if a.scopesClaim == "" {
+		a.scopesClaim = defaultScopesClaim
+	}
+
+	if a.jwksFile != "" || a.jwksURL != "" {
+		if err := a.RefreshKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// RefreshKeys - reloads the key set from the configured file or URL
+func (a *JWTAuthenticator) RefreshKeys() error {
+
+	// the attempt is recorded before fetching so failures are also throttled
+	a.mutex.Lock()
+	a.lastAttempt = time.Now()
+	a.mutex.Unlock()
+
+	set := jwks{}
+
+	if a.jwksFile != "" {
+		if err := loader.ConfJson(a.jwksFile, &set); err != nil {
+			return err
+		}
+	} else {
+		res, err := a.httpClient.Get(a.jwksURL)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status loading the key set: %d", res.StatusCode)
+		}
+
+		if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+			return err
+		}
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != algRS256) {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return err
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.keys = keys
+	a.lastRefresh = time.Now()
+
+	return nil
+}
+
+// key - returns the public key of the key id, refreshing the key set when needed
+func (a *JWTAuthenticator) key(kid string) *rsa.PublicKey {
+
+	a.mutex.RLock()
+	key, ok := a.keys[kid]
+	expired := a.refreshInterval > 0 && time.Since(a.lastRefresh) > a.refreshInterval
+	lastAttempt := a.lastAttempt
+	a.mutex.RUnlock()
+
+	if (ok && !expired) || time.Since(lastAttempt) < defaultJWKSMinRefresh {
+		return key
+	}
+
+	// concurrent requests wait for a single refresh
+	a.refreshMutex.Lock()
+
+	a.mutex.RLock()
+	refreshed := a.lastAttempt.After(lastAttempt)
+	a.mutex.RUnlock()
+
+	if !refreshed {
+		if err := a.RefreshKeys(); err != nil {
+			if logh.ErrorEnabled {
+				a.logger.Error().Err(err).Msg("error refreshing the key set")
+			}
+		}
+	}
+
+	a.refreshMutex.Unlock()
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.keys[kid]
+}
+
+// verify - verifies the token signature
+func (a *JWTAuthenticator) verify(header *jwtHeader, signed string, signature []byte) error {
+
+	switch header.Alg {
+	case algHS256:
+		if len(a.secret) == 0 {
+			return errors.New("HS256 not enabled")
+		}
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case algRS256:
+		if a.jwksFile == "" && a.jwksURL == "" {
+			return errors.New("RS256 not enabled")
+		}
+		key := a.key(header.Kid)
+		if key == nil {
+			return errors.New("unknown key id")
+		}
+		hash := sha256.Sum256([]byte(signed))
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	default:
+		return fmt.Errorf("unsupported algorithm: %s", header.Alg)
+	}
+}
+
+// parse - parses and verifies the token returning its claims
+func (a *JWTAuthenticator) parse(token string) (map[string]interface{}, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	header := jwtHeader{}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.verify(&header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(string(rawClaims)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validate - validates the registered claims
+func (a *JWTAuthenticator) validate(claims map[string]interface{}) error {
+
+	now := time.Now()
+
+	if exp, ok := numericClaim(claims, "exp"); ok && now.After(exp.Add(a.leeway)) {
+		return errors.New("token expired")
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(a.leeway).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return errors.New("invalid issuer")
+		}
+	}
+
+	if a.audience != "" {
+		found := false
+		for _, aud := range stringsClaim(claims, "aud") {
+			if aud == a.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("invalid audience")
+		}
+	}
+
+	return nil
+}
+
+// numericClaim - returns a NumericDate claim as time
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+// stringsClaim - returns a claim with a string, a space separated string or an array of strings
+func stringsClaim(claims map[string]interface{}, name string) []string {
+
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// Authenticate - implements the Authenticator interface
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, gobol.Error) {
+
+	authorization := r.Header.Get(headerAuthorization)
+	if len(authorization) <= len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return nil, nil
+	}
+
+	claims, err := a.parse(strings.TrimSpace(authorization[len(bearerPrefix):]))
+	if err != nil {
+		return nil, errUnauthorized("Authenticate", err)
+	}
+
+	if err := a.validate(claims); err != nil {
+		return nil, errUnauthorized("Authenticate", err)
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return &Principal{
+		ID:     subject,
+		Method: "jwt",
+		Roles:  stringsClaim(claims, a.rolesClaim),
+		Scopes: stringsClaim(claims, a.scopesClaim),
+		Claims: claims,
+	}, nil
+}
+
+// Challenge - implements the Authenticator interface
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
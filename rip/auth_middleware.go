@@ -0,0 +1,145 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/uol/gobol"
+)
+
+const (
+	principalKey dummyKeyType = 2
+
+	headerAuthorization   = "Authorization"
+	headerWWWAuthenticate = "WWW-Authenticate"
+
+	msgUnauthorized string = "authentication required"
+	msgForbidden    string = "access denied"
+)
+
+// Principal - the authenticated caller
+type Principal struct {
+
+	// ID - the principal identifier (key owner, user name or token subject)
+	ID string
+
+	// Method - the authentication method used ("apikey", "basic", "jwt" or "hmac")
+	Method string
+
+	// Roles - the roles granted to the principal
+	Roles []string
+
+	// Scopes - the scopes granted to the principal
+	Scopes []string
+
+	// Claims - the token claims when authenticated by JWT
+	Claims map[string]interface{}
+}
+
+// HasRole - checks if the principal has the role
+func (p *Principal) HasRole(role string) bool {
+
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope - checks if the principal has the scope
+func (p *Principal) HasScope(scope string) bool {
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContextWithPrincipal - returns a copy of the context carrying the principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext - returns the authenticated principal or nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+
+	principal, _ := ctx.Value(principalKey).(*Principal)
+
+	return principal
+}
+
+// Authenticator - authenticates a request
+type Authenticator interface {
+
+	// Authenticate - returns the principal, or nil without errors when the request has no credentials of this kind
+	Authenticate(r *http.Request) (*Principal, gobol.Error)
+
+	// Challenge - the WWW-Authenticate challenge sent on 401 responses
+	Challenge() string
+}
+
+// errUnauthorized - the error returned for missing or invalid credentials
+func errUnauthorized(function string, e error) gobol.Error {
+	return errBasic("rip", function, msgUnauthorized, http.StatusUnauthorized, e)
+}
+
+// errForbidden - the error returned for valid credentials not allowed to access
+func errForbidden(function string, e error) gobol.Error {
+	return errBasic("rip", function, msgForbidden, http.StatusForbidden, e)
+}
+
+// AuthenticationHandler - authenticates the requests putting the principal in the request context
+type AuthenticationHandler struct {
+	next           http.Handler
+	authenticators []Authenticator
+}
+
+// NewAuthenticationMiddleware - creates a new instance of AuthenticationHandler, the authenticators are tried in order
+func NewAuthenticationMiddleware(next http.Handler, authenticators ...Authenticator) *AuthenticationHandler {
+
+	return &AuthenticationHandler{
+		next:           next,
+		authenticators: authenticators,
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	for _, authenticator := range h.authenticators {
+
+		principal, gerr := authenticator.Authenticate(r)
+		if gerr != nil {
+			h.fail(w, r, gerr)
+			return
+		}
+
+		if principal != nil {
+			h.next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+			return
+		}
+	}
+
+	h.fail(w, r, errUnauthorized("ServeHTTP", errors.New("no credentials")))
+}
+
+// fail - sends the error adding the authentication challenges on 401
+func (h *AuthenticationHandler) fail(w http.ResponseWriter, r *http.Request, gerr gobol.Error) {
+
+	if gerr.StatusCode() == http.StatusUnauthorized {
+		for _, authenticator := range h.authenticators {
+			if challenge := authenticator.Challenge(); challenge != "" {
+				w.Header().Add(headerWWWAuthenticate, challenge)
+			}
+		}
+	}
+
+	FailContext(r.Context(), w, gerr)
+}
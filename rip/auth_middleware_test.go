@@ -0,0 +1,238 @@
+package rip
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// signToken - creates a signed JWT
+func signToken(t *testing.T, alg, kid string, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+// serveAuthenticated - serves a request returning the status and the principal found by the handler
+func serveAuthenticated(authenticators []Authenticator, r *http.Request) (int, *Principal) {
+
+	var principal *Principal
+	handler := NewAuthenticationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = PrincipalFromContext(r.Context())
+	}), authenticators...)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w.Code, principal
+}
+
+func TestJWTHS256(t *testing.T) {
+
+	a, err := NewJWTAuthenticator(&JWTConfiguration{Secret: "secret", Issuer: "gobol"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hs256 := func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+
+	m := []Authenticator{a}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(headerAuthorization, "Bearer "+signToken(t, algHS256, "", map[string]interface{}{
+		"sub": "user", "iss": "gobol", "exp": time.Now().Add(time.Minute).Unix(), "scope": "read write",
+	}, hs256))
+
+	status, principal := serveAuthenticated(m, r)
+	if assert.Equal(t, http.StatusOK, status) && assert.NotNil(t, principal) {
+		assert.Equal(t, "user", principal.ID)
+		assert.True(t, principal.HasScope("write"))
+	}
+
+	r.Header.Set(headerAuthorization, "Bearer "+signToken(t, algHS256, "", map[string]interface{}{
+		"sub": "user", "iss": "gobol", "exp": time.Now().Add(-time.Minute).Unix(),
+	}, hs256))
+
+	status, _ = serveAuthenticated(m, r)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	r.Header.Set(headerAuthorization, "Bearer "+signToken(t, "none", "", map[string]interface{}{"sub": "user", "iss": "gobol"}, func([]byte) []byte { return nil }))
+
+	status, _ = serveAuthenticated(m, r)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestJWTRS256FromFile(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, _ := json.Marshal(jwks{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: "k1",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(file, set, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewJWTAuthenticator(&JWTConfiguration{JWKSFile: file, RolesClaim: "groups"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rs256 := func(signed []byte) []byte {
+		hash := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(headerAuthorization, "Bearer "+signToken(t, algRS256, "k1", map[string]interface{}{
+		"sub": "service", "groups": []string{"admin"},
+	}, rs256))
+
+	status, principal := serveAuthenticated([]Authenticator{a}, r)
+	if assert.Equal(t, http.StatusOK, status) && assert.NotNil(t, principal) {
+		assert.True(t, principal.HasRole("admin"))
+	}
+}
+
+func TestJWKSRefreshThrottled(t *testing.T) {
+
+	var fetches int32
+	var failing int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer server.Close()
+
+	a, err := NewJWTAuthenticator(&JWTConfiguration{JWKSURL: server.URL})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	atomic.StoreInt32(&failing, 1)
+	a.mutex.Lock()
+	a.lastAttempt = time.Now().Add(-2 * defaultJWKSMinRefresh)
+	a.mutex.Unlock()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, a.key("unknown"))
+		}()
+	}
+	wg.Wait()
+
+	assert.Nil(t, a.key("unknown"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches), "failed refreshes must be throttled and concurrent ones coalesced")
+}
+
+func TestBasicAndAPIKeyChain(t *testing.T) {
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	basic, err := NewBasicAuthenticator(&BasicConfiguration{
+		Realm: "gobol",
+		Users: []BasicUser{{Name: "user", PasswordHash: string(hash), Roles: []string{"reader"}}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	apiKey, err := NewAPIKeyAuthenticator(&APIKeyConfiguration{
+		Keys: []APIKey{
+			{Key: "k1", Principal: "producer"},
+			{Key: "k2", Principal: "producer", Disabled: true},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m := []Authenticator{apiKey, basic}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("user", "pass")
+	status, principal := serveAuthenticated(m, r)
+	if assert.Equal(t, http.StatusOK, status) && assert.NotNil(t, principal) {
+		assert.Equal(t, "basic", principal.Method)
+		assert.True(t, principal.HasRole("reader"))
+	}
+
+	r.SetBasicAuth("user", "wrong")
+	status, _ = serveAuthenticated(m, r)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(defaultAPIKeyHeader, "k1")
+	status, principal = serveAuthenticated(m, r)
+	if assert.Equal(t, http.StatusOK, status) && assert.NotNil(t, principal) {
+		assert.Equal(t, "producer", principal.ID)
+	}
+
+	r.Header.Set(defaultAPIKeyHeader, "k2")
+	status, _ = serveAuthenticated(m, r)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, _ = serveAuthenticated(m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
@@ -0,0 +1,218 @@
+package rip
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+const defaultForbiddenErrorCode = "forbidden"
+
+// AuthorizationRule - the roles and scopes required by a route
+type AuthorizationRule struct {
+
+	// Roles - the principal must have at least one of these roles
+	Roles []string
+
+	// Scopes - the principal must have all these scopes
+	Scopes []string
+
+	// Public - no authorization required, even when denying by default
+	Public bool
+}
+
+// empty - checks if the rule has no requirements
+func (rule *AuthorizationRule) empty() bool {
+	return rule == nil || (len(rule.Roles) == 0 && len(rule.Scopes) == 0)
+}
+
+// AuthorizationConfiguration - configures the authorizer
+type AuthorizationConfiguration struct {
+
+	// DenyByDefault - denies the routes registered without roles or scopes unless marked as public, the handles added
+	// straight to the httprouter.Router are denied too when the authorizer serves the requests (see ServeHTTP)
+	DenyByDefault bool
+
+	// ErrorCode - the error code of the 403 response, mapped by the error messages file ("forbidden" by default)
+	ErrorCode string
+}
+
+// Authorizer - registers routes on the custom router checking the principal authorization
+type Authorizer struct {
+	router        *httprouter.Router
+	registered    *httprouter.Router
+	denyByDefault bool
+	errorCode     string
+	logger        *logh.ContextualLogger
+}
+
+// NewAuthorizer - creates a new authorizer registering routes on the router (nil when only used as a Router middleware)
+func NewAuthorizer(router *httprouter.Router, configuration *AuthorizationConfiguration) *Authorizer {
+
+	if configuration == nil {
+		configuration = &AuthorizationConfiguration{}
+	}
+
+	errorCode := configuration.ErrorCode
+	if errorCode == "" {
+		errorCode = defaultForbiddenErrorCode
+	}
+
+	return &Authorizer{
+		router:        router,
+		registered:    httprouter.New(),
+		denyByDefault: configuration.DenyByDefault,
+		errorCode:     errorCode,
+		logger:        logh.CreateContextualLogger("pkg", "rip", "type", "audit"),
+	}
+}
+
+// Authorize - checks the principal in the request context against the rule
+func (a *Authorizer) Authorize(r *http.Request, rule *AuthorizationRule) gobol.Error {
+
+	if rule != nil && rule.Public {
+		return nil
+	}
+
+	if rule.empty() && !a.denyByDefault {
+		return nil
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		return errUnauthorized("Authorize", errors.New("no principal"))
+	}
+
+	if rule.empty() {
+		return a.deny(r, principal, rule, "route without authorization rules")
+	}
+
+	if len(rule.Roles) > 0 {
+		found := false
+		for _, role := range rule.Roles {
+			if principal.HasRole(role) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return a.deny(r, principal, rule, "missing role")
+		}
+	}
+
+	for _, scope := range rule.Scopes {
+		if !principal.HasScope(scope) {
+			return a.deny(r, principal, rule, "missing scope")
+		}
+	}
+
+	return nil
+}
+
+// deny - logs the denial and returns the 403 error
+func (a *Authorizer) deny(r *http.Request, principal *Principal, rule *AuthorizationRule, reason string) gobol.Error {
+
+	if logh.WarnEnabled {
+		ev := gobol.LogWithRequestID(r.Context(), a.logger.Warn()).
+			Str("principal", principal.ID).
+			Str("auth", principal.Method).
+			Str("method", r.Method).
+			Str("path", r.URL.Path)
+
+		if rule != nil {
+			ev = ev.Strs("roles", rule.Roles).Strs("scopes", rule.Scopes)
+		}
+
+		ev.Msg("access denied: " + reason)
+	}
+
+	return errWithCode("rip", "Authorize", msgForbidden, a.errorCode, http.StatusForbidden, errors.New(reason))
+}
+
+// Require - wraps the handle checking the rule before calling it
+func (a *Authorizer) Require(rule *AuthorizationRule, handle httprouter.Handle) httprouter.Handle {
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		if gerr := a.Authorize(r, rule); gerr != nil {
+			FailContext(r.Context(), w, gerr)
+			return
+		}
+
+		handle(w, r, ps)
+	}
+}
+
+// Middleware - checks the authorization rule of the route matched by the Router (see the Authorization route option)
+func (a *Authorizer) Middleware() Middleware {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			var rule *AuthorizationRule
+			if route := RouteFromContext(r.Context()); route != nil {
+				rule = route.Authorization
+			}
+
+			if gerr := a.Authorize(r, rule); gerr != nil {
+				FailContext(r.Context(), w, gerr)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handle - registers a route requiring the rule (nil rules are denied when denying by default)
+func (a *Authorizer) Handle(method, path string, rule *AuthorizationRule, handle httprouter.Handle) {
+
+	a.router.Handle(method, path, a.Require(rule, handle))
+	a.registered.Handle(method, path, handle)
+}
+
+// ServeHTTP - serves the requests through the router, when denying by default the handles not registered through the
+// authorizer are denied as routes without rules, so serve the authorizer instead of the router
+func (a *Authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if a.denyByDefault {
+		if handle, _, _ := a.router.Lookup(r.Method, r.URL.Path); handle != nil {
+			if registered, _, _ := a.registered.Lookup(r.Method, r.URL.Path); registered == nil {
+				if gerr := a.Authorize(r, nil); gerr != nil {
+					FailContext(r.Context(), w, gerr)
+					return
+				}
+			}
+		}
+	}
+
+	a.router.ServeHTTP(w, r)
+}
+
+// GET - registers a GET route requiring the rule
+func (a *Authorizer) GET(path string, rule *AuthorizationRule, handle httprouter.Handle) {
+	a.Handle(http.MethodGet, path, rule, handle)
+}
+
+// POST - registers a POST route requiring the rule
+func (a *Authorizer) POST(path string, rule *AuthorizationRule, handle httprouter.Handle) {
+	a.Handle(http.MethodPost, path, rule, handle)
+}
+
+// PUT - registers a PUT route requiring the rule
+func (a *Authorizer) PUT(path string, rule *AuthorizationRule, handle httprouter.Handle) {
+	a.Handle(http.MethodPut, path, rule, handle)
+}
+
+// PATCH - registers a PATCH route requiring the rule
+func (a *Authorizer) PATCH(path string, rule *AuthorizationRule, handle httprouter.Handle) {
+	a.Handle(http.MethodPatch, path, rule, handle)
+}
+
+// DELETE - registers a DELETE route requiring the rule
+func (a *Authorizer) DELETE(path string, rule *AuthorizationRule, handle httprouter.Handle) {
+	a.Handle(http.MethodDelete, path, rule, handle)
+}
@@ -0,0 +1,79 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizerRoutes(t *testing.T) {
+
+	ok := func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {}
+
+	router := httprouter.New()
+	authorizer := NewAuthorizer(router, &AuthorizationConfiguration{DenyByDefault: true, ErrorCode: "denied"})
+
+	authorizer.GET("/admin", &AuthorizationRule{Roles: []string{"admin", "ops"}}, ok)
+	authorizer.POST("/documents", &AuthorizationRule{Scopes: []string{"read", "write"}}, ok)
+	authorizer.GET("/public", &AuthorizationRule{Public: true}, ok)
+	authorizer.GET("/undeclared", nil, ok)
+
+	// registered straight on the router, denied as a route without rules
+	router.GET("/bypass", ok)
+
+	serve := func(method, path string, principal *Principal) int {
+		r := httptest.NewRequest(method, path, nil)
+		if principal != nil {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), principal))
+		}
+		w := httptest.NewRecorder()
+		authorizer.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	ops := &Principal{ID: "ops", Roles: []string{"ops"}}
+	writer := &Principal{ID: "writer", Scopes: []string{"read", "write"}}
+	reader := &Principal{ID: "reader", Scopes: []string{"read"}}
+
+	tests := []struct {
+		method    string
+		path      string
+		principal *Principal
+		expected  int
+	}{
+		{http.MethodGet, "/admin", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/admin", ops, http.StatusOK},
+		{http.MethodGet, "/admin", writer, http.StatusForbidden},
+		{http.MethodPost, "/documents", writer, http.StatusOK},
+		{http.MethodPost, "/documents", reader, http.StatusForbidden},
+		{http.MethodGet, "/public", nil, http.StatusOK},
+		{http.MethodGet, "/undeclared", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/undeclared", ops, http.StatusForbidden},
+		{http.MethodGet, "/bypass", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/bypass", ops, http.StatusForbidden},
+		{http.MethodGet, "/missing", nil, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, serve(test.method, test.path, test.principal), test.method+" "+test.path)
+	}
+}
+
+func TestAuthorizerAllowByDefault(t *testing.T) {
+
+	authorizer := NewAuthorizer(nil, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, authorizer.Authorize(r, nil), "routes without rules are allowed by default")
+	assert.NotNil(t, authorizer.Authorize(r, &AuthorizationRule{Roles: []string{"admin"}}))
+
+	r = r.WithContext(ContextWithPrincipal(r.Context(), &Principal{ID: "user"}))
+	gerr := authorizer.Authorize(r, &AuthorizationRule{Roles: []string{"admin"}})
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusForbidden, gerr.StatusCode())
+		assert.Equal(t, defaultForbiddenErrorCode, gerr.ErrorCode())
+	}
+}
@@ -0,0 +1,338 @@
+package rip
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/gobol"
+)
+
+const (
+	tagBindQuery    = "query"
+	tagBindPath     = "path"
+	tagBindDefault  = "default"
+	tagBindFormat   = "format"
+	optionRequired  = "required"
+	optionNoSplit   = "nosplit"
+	formatUnix      = "unix"
+	formatUnixMilli = "unixms"
+	msgInvalidParam = "invalid parameters"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bindingCache        sync.Map
+)
+
+// FieldError - a parameter that could not be bound
+type FieldError struct {
+	Field   string `json:"field"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+// fieldErrors - the error returned when one or more parameters could not be bound
+type fieldErrors struct {
+	customError
+	fields []FieldError
+}
+
+// FieldErrors - returns the parameters that could not be bound
+func (e fieldErrors) FieldErrors() []FieldError {
+	return e.fields
+}
+
+// fieldErrorsProvider - implemented by the errors carrying field errors, rendered by Fail
+type fieldErrorsProvider interface {
+	FieldErrors() []FieldError
+}
+
+// boundField - a struct field bound from a parameter
+type boundField struct {
+	index        int
+	in           string
+	name         string
+	required     bool
+	split        bool
+	format       string
+	defaultValue string
+	hasDefault   bool
+}
+
+// bindingFields - returns the bound fields of the struct type, cached
+func bindingFields(t reflect.Type) []boundField {
+
+	if cached, ok := bindingCache.Load(t); ok {
+		return cached.([]boundField)
+	}
+
+	fields := []boundField{}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		// unexported fields can not be set
+		if field.PkgPath != "" {
+			continue
+		}
+
+		in := tagBindPath
+		tag, ok := field.Tag.Lookup(tagBindPath)
+		if !ok {
+			in = tagBindQuery
+			if tag, ok = field.Tag.Lookup(tagBindQuery); !ok {
+				continue
+			}
+		}
+
+		options := strings.Split(tag, ",")
+
+		bf := boundField{
+			index:  i,
+			in:     in,
+			name:   options[0],
+			split:  true,
+			format: field.Tag.Get(tagBindFormat),
+		}
+
+		if bf.name == "" {
+			bf.name = field.Name
+		}
+
+		for _, option := range options[1:] {
+			switch option {
+			case optionRequired:
+				bf.required = true
+			case optionNoSplit:
+				bf.split = false
+			}
+		}
+
+		bf.defaultValue, bf.hasDefault = field.Tag.Lookup(tagBindDefault)
+
+		fields = append(fields, bf)
+	}
+
+	bindingCache.Store(t, fields)
+
+	return fields
+}
+
+// Bind - binds the path parameters and the query string to the struct pointed by v using the "path" and "query"
+// field tags, the tag options "required" and "nosplit" (slices are filled by repeated or comma separated values),
+// the "default" tag and the "format" tag for times (a layout, "unix" or "unixms", RFC3339 by default)
+func Bind(r *http.Request, ps httprouter.Params, v interface{}) gobol.Error {
+	return bind(r.URL.Query(), ps, v)
+}
+
+// BindQuery - same as Bind for the query string only
+func BindQuery(values url.Values, v interface{}) gobol.Error {
+	return bind(values, nil, v)
+}
+
+// bind - binds the values to the struct pointed by v
+func bind(query url.Values, ps httprouter.Params, v interface{}) gobol.Error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errBasic("rip", "Bind", "binding target must be a pointer to a struct", http.StatusInternalServerError, fmt.Errorf("invalid binding target %T", v))
+	}
+
+	rv = rv.Elem()
+
+	var failures []FieldError
+
+	for _, bf := range bindingFields(rv.Type()) {
+
+		var values []string
+
+		if bf.in == tagBindPath {
+			if value := ps.ByName(bf.name); value != "" {
+				values = []string{value}
+			}
+		} else {
+			values = query[bf.name]
+		}
+
+		if len(values) == 0 {
+			if bf.required {
+				failures = append(failures, FieldError{Field: bf.name, In: bf.in, Message: "is required"})
+				continue
+			}
+			if !bf.hasDefault {
+				continue
+			}
+			values = []string{bf.defaultValue}
+		}
+
+		if err := setValues(rv.Field(bf.index), values, &bf); err != nil {
+			failures = append(failures, FieldError{Field: bf.name, In: bf.in, Message: err.Error()})
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return newFieldErrors("Bind", failures)
+}
+
+// newFieldErrors - creates the error rendering the field errors
+func newFieldErrors(function string, failures []FieldError) gobol.Error {
+
+	details := make([]string, len(failures))
+	for i, failure := range failures {
+		details[i] = failure.Field + ": " + failure.Message
+	}
+
+	return fieldErrors{
+		customError: customError{
+			errors.New(strings.Join(details, "; ")),
+			msgInvalidParam,
+			"rip",
+			function,
+			http.StatusBadRequest,
+			"",
+		},
+		fields: failures,
+	}
+}
+
+// setValues - sets the field, parsing one value or all values for slices
+func setValues(field reflect.Value, values []string, bf *boundField) error {
+
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 && !field.Addr().Type().Implements(textUnmarshalerType) {
+
+		if bf.split {
+			splitted := make([]string, 0, len(values))
+			for _, value := range values {
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						splitted = append(splitted, item)
+					}
+				}
+			}
+			values = splitted
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value, bf.format); err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setValue(field, values[0], bf.format)
+}
+
+// setValue - parses the value according to the field type
+func setValue(field reflect.Value, value, format string) error {
+
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setValue(ptr.Elem(), value, format); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.Type() == timeType {
+		t, err := parseTime(value, format)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration")
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return errors.New("is invalid: " + err.Error())
+		}
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(n)
+	default:
+		return errors.New("has an unsupported type " + field.Type().String())
+	}
+
+	return nil
+}
+
+// parseTime - parses the time using the format
+func parseTime(value, format string) (time.Time, error) {
+
+	switch format {
+	case "":
+		format = time.RFC3339
+	case formatUnix, formatUnixMilli:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.New("must be an unix timestamp")
+		}
+		if format == formatUnix {
+			return time.Unix(n, 0), nil
+		}
+		return time.Unix(0, n*int64(time.Millisecond)), nil
+	}
+
+	t, err := time.Parse(format, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be a time formatted as " + format)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,121 @@
+package rip
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindingRequest struct {
+	Collection string        `path:"collection,required"`
+	Query      string        `query:"q,required"`
+	Limit      int           `query:"limit" default:"10"`
+	Exact      bool          `query:"exact"`
+	Timeout    time.Duration `query:"timeout" default:"1s"`
+	From       time.Time     `query:"from" format:"2006-01-02"`
+	Until      time.Time     `query:"until" format:"unix"`
+	IDs        []int64       `query:"id"`
+	Terms      []string      `query:"term,nosplit"`
+	Score      *float64      `query:"score"`
+	Ignored    string
+}
+
+func TestBind(t *testing.T) {
+
+	values := url.Values{
+		"q":     {"news"},
+		"exact": {"true"},
+		"from":  {"2020-06-01"},
+		"until": {"1593561600"},
+		"id":    {"1,2", "3"},
+		"term":  {"a,b", "c"},
+		"score": {"0.5"},
+	}
+
+	ps := httprouter.Params{{Key: "collection", Value: "articles"}}
+
+	req := bindingRequest{}
+	if !assert.Nil(t, bind(values, ps, &req)) {
+		return
+	}
+
+	assert.Equal(t, "articles", req.Collection)
+	assert.Equal(t, "news", req.Query)
+	assert.Equal(t, 10, req.Limit)
+	assert.True(t, req.Exact)
+	assert.Equal(t, time.Second, req.Timeout)
+	assert.Equal(t, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), req.From)
+	assert.Equal(t, int64(1593561600), req.Until.Unix())
+	assert.Equal(t, []int64{1, 2, 3}, req.IDs)
+	assert.Equal(t, []string{"a,b", "c"}, req.Terms)
+	if assert.NotNil(t, req.Score) {
+		assert.Equal(t, 0.5, *req.Score)
+	}
+}
+
+func TestBindUnexportedFields(t *testing.T) {
+
+	req := struct {
+		Query string `query:"q"`
+		limit int    `query:"limit" default:"10"`
+	}{}
+
+	assert.NotPanics(t, func() {
+		assert.Nil(t, bind(url.Values{"q": {"news"}, "limit": {"5"}}, nil, &req))
+	})
+
+	assert.Equal(t, "news", req.Query)
+	assert.Equal(t, 0, req.limit, "unexported fields must be skipped")
+}
+
+func TestBindFieldErrors(t *testing.T) {
+
+	values := url.Values{
+		"limit":   {"ten"},
+		"timeout": {"1 second"},
+		"id":      {"1,x"},
+	}
+
+	gerr := BindQuery(values, &bindingRequest{})
+	if !assert.NotNil(t, gerr) {
+		return
+	}
+
+	assert.Equal(t, http.StatusBadRequest, gerr.StatusCode())
+
+	provider, ok := gerr.(fieldErrorsProvider)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.Equal(t, []FieldError{
+		{Field: "collection", In: "path", Message: "is required"},
+		{Field: "q", In: "query", Message: "is required"},
+		{Field: "limit", In: "query", Message: "must be an integer"},
+		{Field: "timeout", In: "query", Message: "must be a duration"},
+		{Field: "id", In: "query", Message: "must be an integer"},
+	}, provider.FieldErrors())
+
+	w := httptest.NewRecorder()
+	Fail(w, gerr)
+
+	body := errorJSON{}
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body)) {
+		assert.Equal(t, msgInvalidParam, body.Message)
+		assert.Len(t, body.Fields, 5)
+	}
+}
+
+func TestBindInvalidTarget(t *testing.T) {
+
+	gerr := BindQuery(url.Values{}, bindingRequest{})
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusInternalServerError, gerr.StatusCode())
+	}
+}
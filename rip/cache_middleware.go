@@ -0,0 +1,543 @@
+package rip
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+)
+
+const (
+	metricCacheHit       string = "http.cache.hit"
+	metricCacheMiss      string = "http.cache.miss"
+	metricCacheCoalesced string = "http.cache.coalesced"
+
+	headerCacheControl = "Cache-Control"
+	headerAge          = "Age"
+	headerXCache       = "X-Cache"
+	headerSetCookie    = "Set-Cookie"
+	headerCookie       = "Cookie"
+
+	cacheHit  = "HIT"
+	cacheMiss = "MISS"
+
+	defaultCacheMaxEntries  = 1000
+	defaultCacheMaxBytes    = 64 << 20
+	defaultCacheMaxBodySize = 1 << 20
+)
+
+var defaultCredentialHeaders = []string{headerAuthorization, headerCookie, defaultAPIKeyHeader, headerSignature}
+
+// CacheConfiguration - configures the response cache middleware
+type CacheConfiguration struct {
+
+	// TTL - the lifetime of the responses of routes without the Cache option, zero caches only the routes with it
+	TTL funks.Duration
+
+	// MaxEntries - the maximum number of responses cached (1000 by default)
+	MaxEntries int
+
+	// MaxBytes - the maximum size of the bodies cached (64MB by default)
+	MaxBytes int64
+
+	// MaxBodySize - larger responses are not cached (1MB by default)
+	MaxBodySize int
+
+	// KeyHeaders - request headers added to the cache key (Accept, Accept-Language...), the requests with a credential
+	// header are cached only if it is listed here
+	KeyHeaders []string
+
+	// CredentialHeaders - the requests with these headers are not cached unless keyed by them (added to Authorization,
+	// Cookie, X-API-Key and X-Signature), the requests with a principal in the context are never cached
+	CredentialHeaders []string
+}
+
+// Cache - sets the cache lifetime of the route responses, used by the cache middleware
+func Cache(ttl time.Duration) RouteOption {
+	return func(route *Route) {
+		route.CacheTTL = ttl
+	}
+}
+
+// cacheEntry - a cached response
+type cacheEntry struct {
+	key     string
+	status  int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// cacheCall - a request in flight, the concurrent requests with the same key wait for its response
+type cacheCall struct {
+	done  chan struct{}
+	entry *cacheEntry
+}
+
+// responseCache - the LRU shared by the cache handlers of a middleware
+type responseCache struct {
+	ttl               time.Duration
+	maxEntries        int
+	maxBytes          int64
+	maxBodySize       int
+	keyHeaders        []string
+	credentialHeaders []string
+	stats             StatisticsInterface
+	mutex             sync.Mutex
+	entries           map[string]*list.Element
+	lru               *list.List
+	size              int64
+	calls             map[string]*cacheCall
+}
+
+// CacheHandler - caches the GET responses in memory using a size bounded LRU
+type CacheHandler struct {
+	*responseCache
+	next http.Handler
+}
+
+// NewCacheMiddleware - creates a new instance of CacheHandler, add its Middleware with Router.Use to configure the TTL
+// per route using the Cache option (statisticsImpl can be nil)
+func NewCacheMiddleware(next http.Handler, configuration *CacheConfiguration, statisticsImpl StatisticsInterface) (*CacheHandler, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	if configuration.TTL.Duration < 0 || configuration.MaxEntries < 0 || configuration.MaxBytes < 0 || configuration.MaxBodySize < 0 {
+		return nil, errors.New("negative cache limits")
+	}
+
+	c := &responseCache{
+		ttl:         configuration.TTL.Duration,
+		maxEntries:  configuration.MaxEntries,
+		maxBytes:    configuration.MaxBytes,
+		maxBodySize: configuration.MaxBodySize,
+		stats:       statisticsImpl,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		calls:       map[string]*cacheCall{},
+	}
+
+	if c.maxEntries == 0 {
+		c.maxEntries = defaultCacheMaxEntries
+	}
+
+	if c.maxBytes == 0 {
+		c.maxBytes = defaultCacheMaxBytes
+	}
+
+	if c.maxBodySize == 0 {
+		c.maxBodySize = defaultCacheMaxBodySize
+	}
+
+	keyed := map[string]struct{}{}
+	for _, header := range configuration.KeyHeaders {
+		header = http.CanonicalHeaderKey(header)
+		keyed[header] = struct{}{}
+		c.keyHeaders = append(c.keyHeaders, header)
+	}
+
+	for _, header := range append(defaultCredentialHeaders, configuration.CredentialHeaders...) {
+		header = http.CanonicalHeaderKey(header)
+		if _, ok := keyed[header]; !ok {
+			c.credentialHeaders = append(c.credentialHeaders, header)
+		}
+	}
+
+	return &CacheHandler{
+		responseCache: c,
+		next:          next,
+	}, nil
+}
+
+// parseCacheControl - parses the Cache-Control directives
+func parseCacheControl(header string) map[string]string {
+
+	directives := map[string]string{}
+
+	for _, directive := range strings.Split(header, ",") {
+
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "" {
+			continue
+		}
+
+		if i := strings.IndexByte(directive, '='); i >= 0 {
+			directives[directive[:i]] = strings.Trim(directive[i+1:], `"`)
+		} else {
+			directives[directive] = ""
+		}
+	}
+
+	return directives
+}
+
+// routeTTL - returns the lifetime of the route responses
+func (c *responseCache) routeTTL(r *http.Request) time.Duration {
+
+	if route := RouteFromContext(r.Context()); route != nil && route.CacheTTL > 0 {
+		return route.CacheTTL
+	}
+
+	return c.ttl
+}
+
+// key - builds the cache key from the path, the sorted query and the selected headers
+func (c *responseCache) key(r *http.Request) string {
+
+	var b strings.Builder
+
+	b.WriteString(r.URL.Path)
+	b.WriteByte('?')
+	b.WriteString(r.URL.Query().Encode())
+
+	for _, header := range c.keyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(header)
+		b.WriteByte(':')
+		b.WriteString(url.QueryEscape(strings.Join(r.Header.Values(header), ",")))
+	}
+
+	return b.String()
+}
+
+// get - returns the cached response if not expired
+func (c *responseCache) get(key string, now time.Time) *cacheEntry {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*cacheEntry)
+
+	if now.After(entry.expires) {
+		c.remove(element)
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry
+}
+
+// store - caches the response evicting the least recently used ones
+func (c *responseCache) store(entry *cacheEntry) {
+
+	if int64(len(entry.body)) > c.maxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[entry.key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += int64(len(entry.body))
+
+	for c.lru.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove - removes the element, must be called with the lock
+func (c *responseCache) remove(element *list.Element) {
+
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.body))
+}
+
+// Len - returns the number of cached responses
+func (c *responseCache) Len() int {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// Purge - removes all cached responses
+func (c *responseCache) Purge() {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.size = 0
+}
+
+// personal - checks if the request is authenticated, its response must not be shared with other clients
+func (c *responseCache) personal(r *http.Request) bool {
+
+	if PrincipalFromContext(r.Context()) != nil {
+		return true
+	}
+
+	for _, header := range c.credentialHeaders {
+		if r.Header.Get(header) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// increment - increments the metric if there is a statistics implementation
+func (c *responseCache) increment(metric string, r *http.Request) {
+
+	if c.stats != nil {
+		c.stats.Increment(metric, tagMethod, r.Method, tagPath, routePattern(r, nil))
+	}
+}
+
+// write - writes the cached response, answering 304 if the client copy is current
+func (c *responseCache) write(w http.ResponseWriter, r *http.Request, entry *cacheEntry, now time.Time) {
+
+	header := w.Header()
+	for k, v := range entry.header {
+		header[k] = v
+	}
+
+	header.Set(headerXCache, cacheHit)
+	header.Set(headerAge, strconv.Itoa(int(now.Sub(entry.stored).Seconds())))
+
+	if etag := entry.header.Get(headerETag); etag != "" && notModified(r, etag, time.Time{}) {
+		header.Del(headerContentType)
+		header.Del(headerContentLength)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// Middleware - returns a middleware sharing the cached responses, for Router.Use or the With route option
+func (h *CacheHandler) Middleware() Middleware {
+
+	return func(next http.Handler) http.Handler {
+		return &CacheHandler{
+			responseCache: h.responseCache,
+			next:          next,
+		}
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *CacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet || h.personal(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	directives := parseCacheControl(r.Header.Get(headerCacheControl))
+	if _, noStore := directives["no-store"]; noStore {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	ttl := h.routeTTL(r)
+	if ttl <= 0 {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	key := h.key(r)
+	now := time.Now()
+
+	_, noCache := directives["no-cache"]
+	if maxAge, ok := directives["max-age"]; ok && maxAge == "0" {
+		noCache = true
+	}
+
+	if noCache {
+		h.increment(metricCacheMiss, r)
+		if entry := h.record(w, r, key, ttl, now); entry != nil {
+			h.store(entry)
+		}
+		return
+	}
+
+	if entry := h.get(key, now); entry != nil {
+		h.increment(metricCacheHit, r)
+		h.write(w, r, entry, now)
+		return
+	}
+
+	h.mutex.Lock()
+	if call, ok := h.calls[key]; ok {
+		h.mutex.Unlock()
+
+		select {
+		case <-call.done:
+		case <-r.Context().Done():
+			return
+		}
+
+		if call.entry != nil {
+			h.increment(metricCacheCoalesced, r)
+			h.write(w, r, call.entry, time.Now())
+			return
+		}
+
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	call := &cacheCall{
+		done: make(chan struct{}),
+	}
+	h.calls[key] = call
+	h.mutex.Unlock()
+
+	defer func() {
+		h.mutex.Lock()
+		delete(h.calls, key)
+		h.mutex.Unlock()
+		close(call.done)
+	}()
+
+	h.increment(metricCacheMiss, r)
+
+	call.entry = h.record(w, r, key, ttl, now)
+	if call.entry != nil {
+		h.store(call.entry)
+	}
+}
+
+// record - serves the request recording the response, returning it if cacheable
+func (h *CacheHandler) record(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, now time.Time) *cacheEntry {
+
+	w.Header().Set(headerXCache, cacheMiss)
+
+	recorder := newCacheResponseWriter(w, h.maxBodySize)
+
+	h.next.ServeHTTP(recorder, r)
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+
+	if recorder.status != http.StatusOK || recorder.exceeded || recorder.header == nil {
+		return nil
+	}
+
+	if recorder.header.Get(headerSetCookie) != "" {
+		return nil
+	}
+
+	directives := parseCacheControl(recorder.header.Get(headerCacheControl))
+
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return nil
+		}
+	}
+
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[directive]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return nil
+			}
+			if maxAge := time.Duration(seconds) * time.Second; maxAge < ttl {
+				ttl = maxAge
+			}
+			break
+		}
+	}
+
+	return &cacheEntry{
+		key:     key,
+		status:  recorder.status,
+		header:  recorder.header,
+		body:    recorder.body,
+		stored:  now,
+		expires: now.Add(ttl),
+	}
+}
+
+// cacheResponseWriter - writes the response to the client keeping a copy up to the limit, the headers kept are only
+// the ones set by the handler, not the ones set by the outer middlewares for this request only (request id, CORS...)
+type cacheResponseWriter struct {
+	http.ResponseWriter
+	before   http.Header
+	status   int
+	header   http.Header
+	body     []byte
+	limit    int
+	exceeded bool
+}
+
+// newCacheResponseWriter - creates the writer taking a snapshot of the headers already set
+func newCacheResponseWriter(w http.ResponseWriter, limit int) *cacheResponseWriter {
+
+	return &cacheResponseWriter{
+		ResponseWriter: w,
+		before:         w.Header().Clone(),
+		limit:          limit,
+	}
+}
+
+// handlerHeaders - returns the headers added or changed since the writer was created
+func (w *cacheResponseWriter) handlerHeaders() http.Header {
+
+	header := http.Header{}
+
+	for k, v := range w.ResponseWriter.Header() {
+		if previous, ok := w.before[k]; !ok || strings.Join(previous, "\n") != strings.Join(v, "\n") {
+			header[k] = append([]string(nil), v...)
+		}
+	}
+
+	return header
+}
+
+func (w *cacheResponseWriter) WriteHeader(s int) {
+
+	if w.status == 0 {
+		w.status = s
+		w.header = w.handlerHeaders()
+	}
+
+	w.ResponseWriter.WriteHeader(s)
+}
+
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.exceeded {
+		if len(w.body)+len(b) > w.limit {
+			w.exceeded = true
+			w.body = nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheResponseWriter) Header() http.Header {
+	return w.ResponseWriter.Header()
+}
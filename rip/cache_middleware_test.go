@@ -0,0 +1,224 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+)
+
+func TestCache(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	var calls int32
+
+	router := NewRouter()
+
+	cache, err := NewCacheMiddleware(nil, &CacheConfiguration{KeyHeaders: []string{"Accept"}}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	router.Use(cache.Middleware())
+
+	router.GET("/facets/:collection", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("private") != "" {
+			w.Header().Set(headerCacheControl, "private")
+		}
+		SuccessJSONWithETag(w, r, http.StatusOK, map[string]int32{ps.ByName("collection"): n}, false)
+		return nil
+	}, Cache(time.Minute))
+
+	router.GET("/uncached", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	serve := func(path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/facets/news?b=2&a=1")
+	assert.Equal(t, cacheMiss, w.Header().Get(headerXCache))
+	assert.JSONEq(t, `{"news":1}`, w.Body.String())
+
+	w = serve("/facets/news?a=1&b=2")
+	assert.Equal(t, cacheHit, w.Header().Get(headerXCache), "the query order must not change the key")
+	assert.JSONEq(t, `{"news":1}`, w.Body.String())
+	assert.Equal(t, mediaTypeJSON, w.Header().Get(headerContentType))
+
+	w = serve("/facets/news?a=1&b=2", headerIfNoneMatch, w.Header().Get(headerETag))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve("/facets/news?a=1&b=2", "Accept", "text/csv")
+	assert.Equal(t, cacheMiss, w.Header().Get(headerXCache), "the selected headers are part of the key")
+
+	w = serve("/facets/news?a=1&b=2", headerCacheControl, "no-cache")
+	assert.Equal(t, cacheMiss, w.Header().Get(headerXCache))
+	assert.JSONEq(t, `{"news":3}`, w.Body.String())
+
+	w = serve("/facets/news?a=1&b=2")
+	assert.JSONEq(t, `{"news":3}`, w.Body.String(), "no-cache must refresh the entry")
+
+	w = serve("/facets/news?a=1&b=2", headerAuthorization, "Bearer token")
+	assert.Empty(t, w.Header().Get(headerXCache), "authorized requests are not cached")
+
+	serve("/facets/news?private=1")
+	w = serve("/facets/news?private=1")
+	assert.Equal(t, cacheMiss, w.Header().Get(headerXCache), "private responses are not cached")
+
+	before := atomic.LoadInt32(&calls)
+	serve("/uncached")
+	serve("/uncached")
+	assert.Equal(t, before+2, atomic.LoadInt32(&calls))
+
+	assert.Equal(t, 3, stats.increments[metricCacheHit])
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/facets/:collection"}, stats.tags[metricCacheHit], "the metric must be tagged with the route pattern")
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestCacheCoalescing(t *testing.T) {
+
+	stats := &statsMock{increments: map[string]int{}}
+
+	var calls int32
+	release := make(chan struct{})
+
+	h, err := NewCacheMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		Success(w, http.StatusOK, []byte("result"))
+	}), &CacheConfiguration{TTL: funks.Duration{Duration: time.Minute}}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const concurrent = 10
+
+	wg := sync.WaitGroup{}
+	wg.Add(concurrent)
+
+	for i := 0; i < concurrent; i++ {
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search", nil))
+			assert.Equal(t, "result", w.Body.String())
+		}()
+	}
+
+	for {
+		h.mutex.Lock()
+		inFlight := len(h.calls)
+		h.mutex.Unlock()
+		if inFlight == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, stats.increments[metricCacheMiss])
+	assert.Equal(t, concurrent-1, stats.increments[metricCacheHit]+stats.increments[metricCacheCoalesced])
+}
+
+func TestCacheEviction(t *testing.T) {
+
+	h, err := NewCacheMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Success(w, http.StatusOK, []byte(r.URL.Path))
+	}), &CacheConfiguration{TTL: funks.Duration{Duration: time.Minute}, MaxEntries: 3}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 5; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+strconv.Itoa(i), nil))
+	}
+
+	assert.Equal(t, 3, h.Len())
+	assert.Nil(t, h.get(h.key(httptest.NewRequest(http.MethodGet, "/0", nil)), time.Now()))
+	assert.NotNil(t, h.get(h.key(httptest.NewRequest(http.MethodGet, "/4", nil)), time.Now()))
+
+	h.Purge()
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestCacheRequestHeaders(t *testing.T) {
+
+	router := NewRouter()
+
+	cache, err := NewCacheMiddleware(nil, &CacheConfiguration{}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	router.Use(cache.Middleware())
+
+	router.GET("/facets", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		w.Header().Set("X-Handler", "facets")
+		SuccessJSON(w, http.StatusOK, map[string]int{"news": 1})
+		return nil
+	}, Cache(time.Minute))
+
+	cors, err := NewCORSMiddleware(router.HTTPRouter(), &CORSConfiguration{AllowedOrigins: []string{"https://a.com", "https://b.com"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	handler := NewRequestIDMiddleware(cors)
+
+	serve := func(origin string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/facets", nil)
+		r.Header.Set(headerOrigin, origin)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := serve("https://a.com")
+	assert.Equal(t, cacheMiss, first.Header().Get(headerXCache))
+
+	second := serve("https://b.com")
+	assert.Equal(t, cacheHit, second.Header().Get(headerXCache))
+	assert.Equal(t, "https://b.com", second.Header().Get(headerAccessControlAllowOrigin))
+	assert.NotEqual(t, first.Header().Get(headerRequestID), second.Header().Get(headerRequestID))
+	assert.Len(t, second.Header().Values(headerRequestID), 1)
+	assert.Equal(t, "facets", second.Header().Get("X-Handler"))
+	assert.Equal(t, mediaTypeJSON, second.Header().Get(headerContentType))
+
+	for _, credential := range []string{headerAuthorization, headerCookie, defaultAPIKeyHeader, headerSignature} {
+		w := serve("https://a.com", credential, "secret")
+		assert.Empty(t, w.Header().Get(headerXCache), credential+" requests must not be cached")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/facets", nil)
+	cache.Middleware()(router).ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), &Principal{ID: "john"})))
+	assert.Empty(t, w.Header().Get(headerXCache), "authenticated requests must not be cached")
+}
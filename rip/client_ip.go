@@ -0,0 +1,103 @@
+package rip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const headerXForwardedFor = "X-Forwarded-For"
+
+// proxyTrust - resolves the client address honoring the X-Forwarded-For header of trusted proxies
+type proxyTrust struct {
+	networks []*net.IPNet
+}
+
+// newProxyTrust - creates a new proxy trust from a list of IPs or CIDRs
+func newProxyTrust(trustedProxies []string) (*proxyTrust, error) {
+
+	p := &proxyTrust{
+		networks: make([]*net.IPNet, 0, len(trustedProxies)),
+	}
+
+	for _, item := range trustedProxies {
+
+		if !strings.Contains(item, "/") {
+			if strings.Contains(item, ":") {
+				item += "/128"
+			} else {
+				item += "/32"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, err
+		}
+
+		p.networks = append(p.networks, network)
+	}
+
+	return p, nil
+}
+
+// trusted - checks if the address belongs to a trusted proxy
+func (p *proxyTrust) trusted(address string) bool {
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP - returns the client address, walking the X-Forwarded-For chain only while the hops are trusted
+func (p *proxyTrust) clientIP(r *http.Request) string {
+
+	address := remoteHost(r.RemoteAddr)
+
+	if p == nil || len(p.networks) == 0 || !p.trusted(address) {
+		return address
+	}
+
+	forwarded := r.Header.Values(headerXForwardedFor)
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+
+		hops := strings.Split(forwarded[i], ",")
+
+		for j := len(hops) - 1; j >= 0; j-- {
+
+			hop := strings.TrimSpace(hops[j])
+			if hop == "" {
+				continue
+			}
+
+			address = hop
+
+			if !p.trusted(hop) {
+				return address
+			}
+		}
+	}
+
+	return address
+}
+
+// remoteHost - removes the port from the remote address
+func remoteHost(remoteAddr string) string {
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,56 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+
+	proxies, err := newProxyTrust([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name       string
+		trust      *proxyTrust
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"no proxies configured", nil, "10.0.0.1:1234", []string{"1.1.1.1"}, "10.0.0.1"},
+		{"untrusted remote", proxies, "8.8.8.8:1234", []string{"1.1.1.1"}, "8.8.8.8"},
+		{"trusted remote without header", proxies, "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"single trusted hop", proxies, "10.0.0.1:1234", []string{"1.1.1.1"}, "1.1.1.1"},
+		{"trusted chain", proxies, "10.0.0.1:1234", []string{"1.1.1.1, 192.168.1.1, 10.0.0.2"}, "1.1.1.1"},
+		{"spoofed first hop", proxies, "10.0.0.1:1234", []string{"6.6.6.6, 1.1.1.1, 10.0.0.2"}, "1.1.1.1"},
+		{"untrusted hop in the middle", proxies, "10.0.0.1:1234", []string{"1.1.1.1, 2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"multiple headers", proxies, "10.0.0.1:1234", []string{"1.1.1.1", "10.0.0.3, 10.0.0.2"}, "1.1.1.1"},
+		{"all hops trusted", proxies, "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"empty hops", proxies, "10.0.0.1:1234", []string{"1.1.1.1,, "}, "1.1.1.1"},
+		{"ipv6 trusted remote", proxies, "[fd00::1]:1234", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"ipv6 untrusted remote", proxies, "[2001:db8::2]:1234", []string{"1.1.1.1"}, "2001:db8::2"},
+		{"remote without port", proxies, "8.8.8.8", nil, "8.8.8.8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwarded {
+				r.Header.Add(headerXForwardedFor, value)
+			}
+			assert.Equal(t, test.expected, test.trust.clientIP(r))
+		})
+	}
+}
+
+func TestProxyTrustInvalid(t *testing.T) {
+
+	_, err := newProxyTrust([]string{"not an ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+package rip
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+)
+
+const (
+	metricRequestInFlight  string = "http.request.inflight"
+	metricRequestShed      string = "http.request.shed"
+	metricConcurrencyLimit string = "http.concurrency.limit"
+	msgServerOverloaded    string = "server overloaded"
+
+	defaultAdaptiveBackoff = 0.9
+)
+
+// ConcurrencyConfiguration - configures the concurrency limit middleware
+type ConcurrencyConfiguration struct {
+
+	// MaxInFlight - the maximum number of requests being served at the same time
+	MaxInFlight int
+
+	// MaxQueue - the number of requests allowed to wait for a free slot (zero sheds immediately)
+	MaxQueue int
+
+	// QueueTimeout - the maximum time waiting in the queue (no limit when zero)
+	QueueTimeout funks.Duration
+
+	// Adaptive - enables the AIMD limit, lowering it when the latency goes above the target
+	Adaptive bool
+
+	// MinInFlight - the lowest limit reached by the adaptive mode (1 by default)
+	MinInFlight int
+
+	// TargetLatency - the latency above which the adaptive mode lowers the limit, at most once per target latency window
+	TargetLatency funks.Duration
+
+	// Backoff - the multiplicative decrease factor of the adaptive mode (0.9 by default)
+	Backoff float64
+}
+
+// ConcurrencyHandler - limits the number of requests in flight, shedding the excess with 503
+type ConcurrencyHandler struct {
+	next          http.Handler
+	mutex         sync.Mutex
+	inFlight      int
+	limit         float64
+	maxLimit      float64
+	minLimit      float64
+	maxQueue      int
+	queue         *list.List
+	queueTimeout  time.Duration
+	adaptive      bool
+	targetLatency time.Duration
+	backoff       float64
+	lastBackoff   time.Time
+	stats         StatisticsInterface
+}
+
+// NewConcurrencyMiddleware - creates a new instance of ConcurrencyHandler, wrap the router for a global limit or a single route handler for a per route limit (statisticsImpl can be nil)
+func NewConcurrencyMiddleware(next http.Handler, configuration *ConcurrencyConfiguration, statisticsImpl StatisticsInterface) (*ConcurrencyHandler, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	if configuration.MaxInFlight <= 0 {
+		return nil, errors.New("the maximum number of requests in flight must be greater than zero")
+	}
+
+	if configuration.Adaptive && configuration.TargetLatency.Duration <= 0 {
+		return nil, errors.New("the adaptive mode requires a target latency")
+	}
+
+	minLimit := configuration.MinInFlight
+	if minLimit <= 0 {
+		minLimit = 1
+	}
+
+	backoff := configuration.Backoff
+	if backoff <= 0 || backoff >= 1 {
+		backoff = defaultAdaptiveBackoff
+	}
+
+	return &ConcurrencyHandler{
+		next:          next,
+		limit:         float64(configuration.MaxInFlight),
+		maxLimit:      float64(configuration.MaxInFlight),
+		minLimit:      math.Min(float64(minLimit), float64(configuration.MaxInFlight)),
+		maxQueue:      configuration.MaxQueue,
+		queue:         list.New(),
+		queueTimeout:  configuration.QueueTimeout.Duration,
+		adaptive:      configuration.Adaptive,
+		targetLatency: configuration.TargetLatency.Duration,
+		backoff:       backoff,
+		stats:         statisticsImpl,
+	}, nil
+}
+
+// acquire - takes a slot, waiting in the queue if allowed
+func (h *ConcurrencyHandler) acquire(ctx context.Context) bool {
+
+	h.mutex.Lock()
+
+	if h.inFlight < int(h.limit) {
+		h.inFlight++
+		h.mutex.Unlock()
+		return true
+	}
+
+	if h.queue.Len() >= h.maxQueue {
+		h.mutex.Unlock()
+		return false
+	}
+
+	ready := make(chan struct{})
+	element := h.queue.PushBack(ready)
+	h.mutex.Unlock()
+
+	var timeout <-chan time.Time
+	if h.queueTimeout > 0 {
+		timer := time.NewTimer(h.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-ready:
+		return true
+	case <-timeout:
+	case <-ctx.Done():
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	select {
+	case <-ready:
+		// the slot was handed over while giving up
+		return true
+	default:
+		h.queue.Remove(element)
+		return false
+	}
+}
+
+// release - frees the slot, handing it over to the queue and adapting the limit, the limit is lowered once per window
+// since the requests slow at the same time would otherwise collapse it
+func (h *ConcurrencyHandler) release(latency time.Duration) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.adaptive {
+		if latency > h.targetLatency {
+			if now := time.Now(); now.Sub(h.lastBackoff) >= h.targetLatency {
+				h.limit = math.Max(h.minLimit, h.limit*h.backoff)
+				h.lastBackoff = now
+			}
+		} else {
+			h.limit = math.Min(h.maxLimit, h.limit+1/h.limit)
+		}
+	}
+
+	h.inFlight--
+
+	// more than one waiter is woken when the limit increases
+	for h.inFlight < int(h.limit) && h.queue.Len() > 0 {
+		h.inFlight++
+		close(h.queue.Remove(h.queue.Front()).(chan struct{}))
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *ConcurrencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !h.acquire(r.Context()) {
+
+		if h.stats != nil {
+			h.stats.Increment(metricRequestShed, tagMethod, r.Method, tagPath, routePattern(r, nil))
+		}
+
+		FailContext(
+			r.Context(),
+			w,
+			errBasic("rip", "ServeHTTP", msgServerOverloaded, http.StatusServiceUnavailable, errors.New(http.StatusText(http.StatusServiceUnavailable))),
+		)
+
+		return
+	}
+
+	if h.stats != nil {
+		h.mutex.Lock()
+		inFlight, limit := h.inFlight, h.limit
+		h.mutex.Unlock()
+
+		h.stats.Maximum(metricRequestInFlight, float64(inFlight))
+		if h.adaptive {
+			h.stats.Maximum(metricConcurrencyLimit, limit)
+		}
+	}
+
+	start := time.Now()
+
+	defer func() {
+		h.release(time.Since(start))
+	}()
+
+	h.next.ServeHTTP(w, r)
+}
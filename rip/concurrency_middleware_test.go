@@ -0,0 +1,137 @@
+package rip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+)
+
+// queued - returns the number of requests waiting for a slot
+func (h *ConcurrencyHandler) queued() int {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.queue.Len()
+}
+
+// waitQueued - waits until the number of requests waiting reaches n
+func waitQueued(h *ConcurrencyHandler, n int) {
+
+	for h.queued() != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrencyQueue(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	h, err := NewConcurrencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &ConcurrencyConfiguration{
+		MaxInFlight: 1,
+		MaxQueue:    1,
+	}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, h.acquire(context.Background()))
+
+	acquired := make(chan bool, 1)
+	go func() {
+		acquired <- h.acquire(context.Background())
+	}()
+	waitQueued(h, 1)
+
+	assert.False(t, h.acquire(context.Background()), "requests beyond the queue must be shed")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/facets/news", nil)
+	h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey, &Route{Path: "/facets/:collection"})))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/facets/:collection"}, stats.tags[metricRequestShed], "the metric must be tagged with the route pattern")
+
+	h.release(0)
+	assert.True(t, <-acquired, "the slot must be handed over to the queue")
+	assert.Equal(t, 1, h.inFlight)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		acquired <- h.acquire(ctx)
+	}()
+	waitQueued(h, 1)
+	cancel()
+
+	assert.False(t, <-acquired)
+	assert.Equal(t, 0, h.queued(), "canceled requests must leave the queue")
+
+	h.release(0)
+	assert.Equal(t, 0, h.inFlight)
+}
+
+func TestConcurrencyQueueTimeout(t *testing.T) {
+
+	h, err := NewConcurrencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &ConcurrencyConfiguration{
+		MaxInFlight:  1,
+		MaxQueue:     1,
+		QueueTimeout: funks.Duration{Duration: 10 * time.Millisecond},
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, h.acquire(context.Background()))
+	assert.False(t, h.acquire(context.Background()))
+	assert.Equal(t, 0, h.queued())
+}
+
+func TestConcurrencyAdaptive(t *testing.T) {
+
+	h, err := NewConcurrencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &ConcurrencyConfiguration{
+		MaxInFlight:   10,
+		MaxQueue:      10,
+		Adaptive:      true,
+		TargetLatency: funks.Duration{Duration: time.Hour},
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, h.acquire(context.Background()))
+	}
+
+	for i := 0; i < 5; i++ {
+		h.release(2 * time.Hour)
+	}
+
+	assert.Equal(t, 9.0, h.limit, "the limit must be lowered once per window")
+
+	h.mutex.Lock()
+	h.limit = 1
+	h.mutex.Unlock()
+
+	assert.True(t, h.acquire(context.Background()))
+
+	acquired := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			acquired <- h.acquire(context.Background())
+		}()
+	}
+	waitQueued(h, 2)
+
+	h.release(0)
+
+	assert.True(t, <-acquired)
+	assert.True(t, <-acquired, "the waiters must be woken when the limit increases")
+	assert.Equal(t, 2, h.inFlight)
+}
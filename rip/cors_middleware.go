@@ -0,0 +1,268 @@
+package rip
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	headerOrigin                        = "Origin"
+	headerAccessControlRequestMethod    = "Access-Control-Request-Method"
+	headerAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	headerAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	headerAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	headerAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	headerAccessControlMaxAge           = "Access-Control-Max-Age"
+)
+
+var routerMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// CORSConfiguration - configures the CORS middleware
+type CORSConfiguration struct {
+
+	// AllowedOrigins - exact origins, "*" for any origin or wildcard subdomains like "https://*.example.com"
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns - regular expressions matching the whole allowed origin (they are always anchored)
+	AllowedOriginPatterns []string
+
+	// AllowedMethods - the allowed methods, when empty the methods registered on the router for the path are used
+	AllowedMethods []string
+
+	// AllowedHeaders - the allowed request headers, when empty the requested headers are allowed
+	AllowedHeaders []string
+
+	// ExposedHeaders - the response headers exposed to the browser
+	ExposedHeaders []string
+
+	// AllowCredentials - allows cookies and authorization headers, not allowed with the "*" origin
+	AllowCredentials bool
+
+	// MaxAge - the number of seconds the preflight response can be cached
+	MaxAge int
+}
+
+// CORSHandler - handles the CORS headers and answers the preflight requests
+type CORSHandler struct {
+	next             http.Handler
+	router           *httprouter.Router
+	anyOrigin        bool
+	origins          map[string]struct{}
+	originWildcards  [][2]string
+	originPatterns   []*regexp.Regexp
+	allowedMethods   string
+	allowedHeaders   map[string]struct{}
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORSMiddleware - creates a new instance of CORSHandler wrapping the router (its registered methods are used on preflights)
+func NewCORSMiddleware(router *httprouter.Router, configuration *CORSConfiguration) (*CORSHandler, error) {
+
+	if configuration == nil {
+		configuration = &CORSConfiguration{}
+	}
+
+	h := &CORSHandler{
+		next:             router,
+		router:           router,
+		origins:          map[string]struct{}{},
+		allowedMethods:   strings.Join(configuration.AllowedMethods, ", "),
+		exposedHeaders:   strings.Join(configuration.ExposedHeaders, ", "),
+		allowCredentials: configuration.AllowCredentials,
+	}
+
+	if configuration.MaxAge > 0 {
+		h.maxAge = strconv.Itoa(configuration.MaxAge)
+	}
+
+	for _, origin := range configuration.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			if configuration.AllowCredentials {
+				return nil, errors.New("credentials can not be allowed for any origin")
+			}
+			h.anyOrigin = true
+		} else if i := strings.Index(origin, "*"); i >= 0 {
+			h.originWildcards = append(h.originWildcards, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			h.origins[origin] = struct{}{}
+		}
+	}
+
+	for _, pattern := range configuration.AllowedOriginPatterns {
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return nil, err
+		}
+		h.originPatterns = append(h.originPatterns, re)
+	}
+
+	if len(configuration.AllowedHeaders) > 0 {
+		h.allowedHeaders = map[string]struct{}{}
+		for _, header := range configuration.AllowedHeaders {
+			h.allowedHeaders[http.CanonicalHeaderKey(header)] = struct{}{}
+		}
+	}
+
+	return h, nil
+}
+
+// originAllowed - checks if the origin is allowed
+func (h *CORSHandler) originAllowed(origin string) bool {
+
+	if h.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+
+	if _, ok := h.origins[origin]; ok {
+		return true
+	}
+
+	for _, wildcard := range h.originWildcards {
+		if len(origin) > len(wildcard[0])+len(wildcard[1]) && strings.HasPrefix(origin, wildcard[0]) && strings.HasSuffix(origin, wildcard[1]) {
+			return true
+		}
+	}
+
+	for _, re := range h.originPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// routeMethods - returns the methods registered on the router for the path
+func (h *CORSHandler) routeMethods(path string) []string {
+
+	methods := []string{}
+	for _, method := range routerMethods {
+		if handle, _, _ := h.router.Lookup(method, path); handle != nil {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+// headersAllowed - checks if all requested headers are allowed
+func (h *CORSHandler) headersAllowed(requested string) bool {
+
+	if h.allowedHeaders == nil || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		if _, ok := h.allowedHeaders[http.CanonicalHeaderKey(strings.TrimSpace(header))]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setOrigin - sets the allowed origin headers
+func (h *CORSHandler) setOrigin(headers http.Header, origin string) {
+
+	if h.anyOrigin && !h.allowCredentials {
+		headers.Set(headerAccessControlAllowOrigin, "*")
+	} else {
+		headers.Set(headerAccessControlAllowOrigin, origin)
+		headers.Add(headerVary, headerOrigin)
+	}
+
+	if h.allowCredentials {
+		headers.Set(headerAccessControlAllowCredentials, "true")
+	}
+}
+
+// preflight - answers a preflight request
+func (h *CORSHandler) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+
+	headers := w.Header()
+	headers.Add(headerVary, headerAccessControlRequestMethod)
+	headers.Add(headerVary, headerAccessControlRequestHeaders)
+
+	methods := h.routeMethods(r.URL.Path)
+	if len(methods) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	requestedMethod := r.Header.Get(headerAccessControlRequestMethod)
+	allowedMethods := h.allowedMethods
+	if allowedMethods == "" {
+		allowedMethods = strings.Join(methods, ", ")
+	}
+
+	methodAllowed := false
+	for _, method := range strings.Split(allowedMethods, ",") {
+		if strings.TrimSpace(method) == requestedMethod {
+			methodAllowed = true
+			break
+		}
+	}
+
+	requestedHeaders := r.Header.Get(headerAccessControlRequestHeaders)
+
+	if !h.originAllowed(origin) || !methodAllowed || !h.headersAllowed(requestedHeaders) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	h.setOrigin(headers, origin)
+	headers.Set(headerAccessControlAllowMethods, allowedMethods)
+
+	if requestedHeaders != "" {
+		headers.Set(headerAccessControlAllowHeaders, requestedHeaders)
+	}
+
+	if h.maxAge != "" {
+		headers.Set(headerAccessControlMaxAge, h.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *CORSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	origin := r.Header.Get(headerOrigin)
+	if origin == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodOptions && r.Header.Get(headerAccessControlRequestMethod) != "" {
+		h.preflight(w, r, origin)
+		return
+	}
+
+	if h.originAllowed(origin) {
+		h.setOrigin(w.Header(), origin)
+		if h.exposedHeaders != "" {
+			w.Header().Set(headerAccessControlExposeHeaders, h.exposedHeaders)
+		}
+	}
+
+	h.next.ServeHTTP(w, r)
+}
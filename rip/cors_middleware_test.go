@@ -0,0 +1,125 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCORSTest - creates a CORS middleware wrapping a router with one route
+func newCORSTest(t *testing.T, configuration *CORSConfiguration) *CORSHandler {
+
+	router := httprouter.New()
+	router.GET("/documents", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+	router.POST("/documents", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+
+	h, err := NewCORSMiddleware(router, configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+func TestCORSOriginAllowed(t *testing.T) {
+
+	h := newCORSTest(t, &CORSConfiguration{
+		AllowedOrigins:        []string{"https://exact.com", "https://*.example.com"},
+		AllowedOriginPatterns: []string{`https://app[0-9]+\.test\.com`, `https://a\.com|https://b\.com`},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://exact.com", true},
+		{"https://EXACT.com", true},
+		{"https://exact.com.evil.com", false},
+		{"https://api.example.com", true},
+		{"https://.example.com", false},
+		{"https://example.com", false},
+		{"https://app1.test.com", true},
+		{"https://app1.test.com.evil.com", false},
+		{"https://evil.com/https://app1.test.com", false},
+		{"https://a.com", true},
+		{"https://b.com", true},
+		{"https://a.com.evil.com", false},
+		{"https://evil.b.com", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, h.originAllowed(test.origin), test.origin)
+	}
+}
+
+func TestCORSInvalidPattern(t *testing.T) {
+
+	_, err := NewCORSMiddleware(httprouter.New(), &CORSConfiguration{AllowedOriginPatterns: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestCORSCredentialsAnyOrigin(t *testing.T) {
+
+	_, err := NewCORSMiddleware(httprouter.New(), &CORSConfiguration{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.Error(t, err, "any site could make credentialed requests")
+}
+
+func TestCORSPreflight(t *testing.T) {
+
+	h := newCORSTest(t, &CORSConfiguration{
+		AllowedOrigins:   []string{"https://a.com"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	preflight := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodOptions, path, nil)
+		r.Header.Set(headerOrigin, origin)
+		r.Header.Set(headerAccessControlRequestMethod, method)
+		if headers != "" {
+			r.Header.Set(headerAccessControlRequestHeaders, headers)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := preflight("/documents", "https://a.com", http.MethodPost, "content-type")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://a.com", w.Header().Get(headerAccessControlAllowOrigin))
+	assert.Equal(t, "true", w.Header().Get(headerAccessControlAllowCredentials))
+	assert.Equal(t, "600", w.Header().Get(headerAccessControlMaxAge))
+	assert.Contains(t, w.Header().Get(headerAccessControlAllowMethods), http.MethodPost)
+
+	assert.Equal(t, http.StatusForbidden, preflight("/documents", "https://b.com", http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusForbidden, preflight("/documents", "https://a.com", http.MethodDelete, "").Code)
+	assert.Equal(t, http.StatusForbidden, preflight("/documents", "https://a.com", http.MethodPost, "X-Other").Code)
+	assert.Equal(t, http.StatusNotFound, preflight("/unknown", "https://a.com", http.MethodGet, "").Code)
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+
+	h := newCORSTest(t, &CORSConfiguration{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Request-ID"},
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/documents", nil)
+	r.Header.Set(headerOrigin, "https://any.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get(headerAccessControlAllowOrigin))
+	assert.Equal(t, "X-Request-ID", w.Header().Get(headerAccessControlExposeHeaders))
+
+	r = httptest.NewRequest(http.MethodGet, "/documents", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Empty(t, w.Header().Get(headerAccessControlAllowOrigin), "requests without origin are not CORS requests")
+}
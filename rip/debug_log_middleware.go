@@ -0,0 +1,321 @@
+package rip
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+const (
+	headerDebugLog  = "X-Debug-Log"
+	debugLogMsg     = "debug"
+	mediaTypeForm   = "application/x-www-form-urlencoded"
+	defaultDebugMax = 64 << 10
+)
+
+var defaultRedactedHeaders = []string{headerAuthorization, "Cookie", headerSetCookie, defaultAPIKeyHeader, headerSignature}
+
+// DebugLogConfiguration - configures the body debug log middleware
+type DebugLogConfiguration struct {
+
+	// SampleRate - the fraction (0 to 1) of requests logged, zero logs only the flagged requests
+	SampleRate float64
+
+	// Secret - the value of the X-Debug-Log header flagging a request to be logged, empty disables the flag
+	Secret string
+
+	// MaxBodySize - the bodies are captured up to this size in bytes (64KB by default)
+	MaxBodySize int
+
+	// RedactedFields - JSON and form fields containing these names are replaced in the logs at any depth, case insensitive
+	// (added to password, secret, token, key and credential)
+	RedactedFields []string
+
+	// RedactedHeaders - headers replaced in the logs (added to Authorization, Cookie, Set-Cookie, X-API-Key and X-Signature)
+	RedactedHeaders []string
+}
+
+// DebugLogHandler - logs the request and response bodies of sampled or flagged requests
+type DebugLogHandler struct {
+	next            http.Handler
+	sampleRate      float64
+	secret          []byte
+	maxBodySize     int
+	redactedFields  []string
+	redactedHeaders map[string]struct{}
+	fieldPattern    *regexp.Regexp
+	valuePattern    *regexp.Regexp
+	logger          *logh.ContextualLogger
+}
+
+// NewDebugLogMiddleware - creates a new instance of DebugLogHandler
+func NewDebugLogMiddleware(next http.Handler, configuration *DebugLogConfiguration) (*DebugLogHandler, error) {
+
+	if configuration == nil {
+		configuration = &DebugLogConfiguration{}
+	}
+
+	h := &DebugLogHandler{
+		next:            next,
+		sampleRate:      configuration.SampleRate,
+		secret:          []byte(configuration.Secret),
+		maxBodySize:     configuration.MaxBodySize,
+		redactedHeaders: map[string]struct{}{},
+		logger:          logh.CreateContextualLogger("pkg", "rip", "type", "debug"),
+	}
+
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = defaultDebugMax
+	}
+
+	quoted := []string{}
+	for _, field := range append(defaultRedactedFields, configuration.RedactedFields...) {
+		h.redactedFields = append(h.redactedFields, strings.ToLower(field))
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+
+	// used on the truncated JSON bodies, which can not be parsed
+	pattern, err := regexp.Compile(`(?i)("[^"]*(?:` + strings.Join(quoted, "|") + `)[^"]*"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	h.fieldPattern = pattern
+
+	// used on the truncated forms and the text bodies, like "password=123&" or "token: 123"
+	pattern, err = regexp.Compile(`(?i)([\w.\-%]*(?:` + strings.Join(quoted, "|") + `)[\w.\-%]*\s*[=:]\s*)("(?:[^"\\]|\\.)*"?|[^\s&,;]+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	h.valuePattern = pattern
+
+	for _, header := range append(defaultRedactedHeaders, configuration.RedactedHeaders...) {
+		h.redactedHeaders[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+
+	// the flag header carries the secret
+	h.redactedHeaders[headerDebugLog] = struct{}{}
+
+	return h, nil
+}
+
+// enabled - checks if the request is flagged or sampled
+func (h *DebugLogHandler) enabled(r *http.Request) bool {
+
+	if !logh.InfoEnabled {
+		return false
+	}
+
+	if flag := r.Header.Get(headerDebugLog); flag != "" && len(h.secret) > 0 {
+		if subtle.ConstantTimeCompare([]byte(flag), h.secret) == 1 {
+			return true
+		}
+	}
+
+	return h.sampleRate > 0 && rand.Float64() < h.sampleRate
+}
+
+// sensitive - checks if the field name contains a redacted name
+func (h *DebugLogHandler) sensitive(field string) bool {
+
+	field = strings.ToLower(field)
+	for _, redacted := range h.redactedFields {
+		if strings.Contains(field, redacted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headers - returns the headers with the redacted values replaced
+func (h *DebugLogHandler) headers(header http.Header) map[string]string {
+
+	result := make(map[string]string, len(header))
+
+	for k, v := range header {
+		if _, ok := h.redactedHeaders[k]; ok {
+			result[k] = redactedValue
+		} else {
+			result[k] = strings.Join(v, ", ")
+		}
+	}
+
+	return result
+}
+
+// redactValue - replaces the redacted fields of the decoded JSON at any depth
+func (h *DebugLogHandler) redactValue(v interface{}) {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if h.sensitive(k) {
+				value[k] = redactedValue
+			} else {
+				h.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			h.redactValue(item)
+		}
+	}
+}
+
+// values - returns the query or form with the redacted fields replaced
+func (h *DebugLogHandler) values(raw string, truncated bool) string {
+
+	if !truncated {
+		if values, err := url.ParseQuery(raw); err == nil {
+			for k := range values {
+				if h.sensitive(k) {
+					values[k] = []string{redactedValue}
+				}
+			}
+			return values.Encode()
+		}
+	}
+
+	return h.valuePattern.ReplaceAllString(raw, `${1}`+redactedValue)
+}
+
+// body - returns the body with the redacted fields replaced according to the content type, multipart and binary
+// bodies are not logged
+func (h *DebugLogHandler) body(contentType string, body []byte, truncated bool) string {
+
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == mediaTypeForm:
+		return h.values(string(body), truncated)
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return "[multipart body omitted]"
+	case strings.HasSuffix(mediaType, "json") || json.Valid(body):
+		if !truncated {
+			var decoded interface{}
+			if err := json.Unmarshal(body, &decoded); err == nil {
+				h.redactValue(decoded)
+				if b, err := json.Marshal(decoded); err == nil {
+					return string(b)
+				}
+			}
+		}
+		return h.fieldPattern.ReplaceAllString(string(body), `${1}"`+redactedValue+`"`)
+	case !utf8.Valid(body):
+		return "[binary body omitted]"
+	}
+
+	return h.valuePattern.ReplaceAllString(string(body), `${1}`+redactedValue)
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *DebugLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !h.enabled(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+
+	requestBody := &captureBuffer{limit: h.maxBodySize}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &captureReadCloser{
+			ReadCloser: r.Body,
+			capture:    requestBody,
+		}
+	}
+
+	responseBody := &captureBuffer{limit: h.maxBodySize}
+	recorder := &debugResponseWriter{
+		LogResponseWriter: LogResponseWriter{ResponseWriter: w},
+		capture:           responseBody,
+	}
+
+	h.next.ServeHTTP(recorder, r)
+
+	status := recorder.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	ev := gobol.LogWithRequestID(r.Context(), h.logger.Info())
+	if ev == nil {
+		return
+	}
+
+	ev.Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Str("query", h.values(r.URL.RawQuery, false)).
+		Int("status", status).
+		Dur("duration", time.Since(start)).
+		Interface("request_headers", h.headers(r.Header)).
+		Str("request_body", h.body(r.Header.Get(headerContentType), requestBody.Bytes(), requestBody.truncated)).
+		Bool("request_truncated", requestBody.truncated).
+		Interface("response_headers", h.headers(w.Header())).
+		Str("response_body", h.body(w.Header().Get(headerContentType), responseBody.Bytes(), responseBody.truncated)).
+		Bool("response_truncated", responseBody.truncated).
+		Msg(debugLogMsg)
+}
+
+// captureBuffer - keeps the first bytes written up to the limit
+type captureBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *captureBuffer) capture(b []byte) {
+
+	if remaining := c.limit - c.Len(); remaining < len(b) {
+		c.truncated = true
+		b = b[:remaining]
+	}
+
+	c.Write(b)
+}
+
+// captureReadCloser - captures the body while the handler reads it
+type captureReadCloser struct {
+	io.ReadCloser
+	capture *captureBuffer
+}
+
+func (c *captureReadCloser) Read(p []byte) (int, error) {
+
+	n, err := c.ReadCloser.Read(p)
+	c.capture.capture(p[:n])
+
+	return n, err
+}
+
+// debugResponseWriter - captures the response body
+type debugResponseWriter struct {
+	LogResponseWriter
+	capture *captureBuffer
+}
+
+func (w *debugResponseWriter) Write(b []byte) (int, error) {
+
+	w.capture.capture(b)
+
+	return w.LogResponseWriter.Write(b)
+}
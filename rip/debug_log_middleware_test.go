@@ -0,0 +1,90 @@
+package rip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/logh"
+)
+
+func TestDebugLogRedaction(t *testing.T) {
+
+	h, err := NewDebugLogMiddleware(nil, &DebugLogConfiguration{RedactedFields: []string{"cpf"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	body := h.body(mediaTypeJSON, []byte(`{"user":"john","Password":"123","profile":{"cpf":"000"},"items":[{"apiKey":"k"}]}`), false)
+	assert.JSONEq(t, `{"user":"john","Password":"***","profile":{"cpf":"***"},"items":[{"apiKey":"***"}]}`, body)
+
+	body = h.body(mediaTypeJSON, []byte(`{"user":"john","user_password":"12\"3","token":12`), true)
+	assert.Equal(t, `{"user":"john","user_password":"***","token":"***"`, body)
+
+	body = h.body(mediaTypeForm, []byte("user=john&password=123"), false)
+	assert.Equal(t, "password=%2A%2A%2A&user=john", body)
+
+	body = h.body(mediaTypeForm, []byte("user=john&user_password=123&cpf=00"), true)
+	assert.Equal(t, "user=john&user_password=***&cpf=***", body, "truncated forms must be redacted")
+
+	body = h.body("text/csv", []byte("id,password"), false)
+	assert.Equal(t, "id,password", body)
+
+	body = h.body("text/plain", []byte("login ok\npassword: 123\ntoken=abc"), false)
+	assert.Equal(t, "login ok\npassword: ***\ntoken=***", body)
+
+	body = h.body("multipart/form-data; boundary=x", []byte("--x\r\npassword\r\n--x--"), false)
+	assert.Equal(t, "[multipart body omitted]", body)
+
+	body = h.body("application/octet-stream", []byte{0xff, 0xfe, 0x00}, false)
+	assert.Equal(t, "[binary body omitted]", body)
+
+	assert.Equal(t, "access_token=%2A%2A%2A&q=news", h.values("q=news&access_token=abc", false))
+	assert.Equal(t, "q=news&access_token=***&x=%zz", h.values("q=news&access_token=abc&x=%zz", false), "invalid queries must be redacted")
+
+	headers := h.headers(http.Header{
+		headerAuthorization: {"Bearer token"},
+		headerDebugLog:      {"secret"},
+		"Accept":            {"a", "b"},
+	})
+	assert.Equal(t, map[string]string{headerAuthorization: "***", headerDebugLog: "***", "Accept": "a, b"}, headers)
+}
+
+func TestDebugLogCapture(t *testing.T) {
+
+	logh.ConfigureGlobalLogger(logh.INFO, logh.JSON)
+
+	var received string
+
+	h, err := NewDebugLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = string(b)
+		Success(w, http.StatusOK, b)
+	}), &DebugLogConfiguration{Secret: "s3cr3t", MaxBodySize: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, h.enabled(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	r := httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader("0123456789"))
+	r.Header.Set(headerDebugLog, "wrong")
+	assert.False(t, h.enabled(r))
+
+	r.Header.Set(headerDebugLog, "s3cr3t")
+	assert.True(t, h.enabled(r))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, "0123456789", received, "the handler must read the whole body")
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	capture := &captureBuffer{limit: 4}
+	capture.capture([]byte("012"))
+	capture.capture([]byte("345"))
+	assert.Equal(t, "0123", capture.String())
+	assert.True(t, capture.truncated)
+}
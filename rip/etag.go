@@ -0,0 +1,223 @@
+package rip
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/uol/gobol"
+)
+
+const (
+	headerContentType       = "Content-Type"
+	headerETag              = "ETag"
+	headerIfMatch           = "If-Match"
+	headerIfNoneMatch       = "If-None-Match"
+	headerIfModifiedSince   = "If-Modified-Since"
+	headerIfUnmodifiedSince = "If-Unmodified-Since"
+	headerLastModified      = "Last-Modified"
+	weakPrefix              = "W/"
+	msgPreconditionFailed   = "precondition failed"
+)
+
+// StrongETag - returns a strong entity tag from the body hash
+func StrongETag(body []byte) string {
+
+	sum := sha256.Sum256(body)
+
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// WeakETag - returns a weak entity tag from the body hash
+func WeakETag(body []byte) string {
+	return weakPrefix + StrongETag(body)
+}
+
+// VersionETag - returns an entity tag from a version supplied by the caller (a revision or an update timestamp)
+func VersionETag(version string, weak bool) string {
+
+	etag := `"` + strings.ReplaceAll(version, `"`, "") + `"`
+	if weak {
+		return weakPrefix + etag
+	}
+
+	return etag
+}
+
+// SetValidators - sets the ETag and Last-Modified headers when not empty
+func SetValidators(w http.ResponseWriter, etag string, lastModified time.Time) {
+
+	if etag != "" {
+		w.Header().Set(headerETag, etag)
+	}
+
+	if !lastModified.IsZero() {
+		w.Header().Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// NotModified - sets the validators and writes 304 if the If-None-Match or If-Modified-Since conditions of a GET or HEAD
+// request match, returning true when the response was written
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+
+	SetValidators(w, etag, lastModified)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if !notModified(r, etag, lastModified) {
+		return false
+	}
+
+	header := w.Header()
+	header.Del(headerContentType)
+	header.Del(headerContentLength)
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// notModified - evaluates If-None-Match, or If-Modified-Since when there is no If-None-Match
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+
+	if ifNoneMatch := r.Header.Get(headerIfNoneMatch); ifNoneMatch != "" {
+		return etag != "" && matchETag(ifNoneMatch, etag, false)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get(headerIfModifiedSince))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// CheckPreconditions - evaluates the If-Match and If-Unmodified-Since headers against the current entity tag and
+// modification time of the resource (empty and zero if it does not exist), returning 412 if they fail
+func CheckPreconditions(r *http.Request, etag string, lastModified time.Time) gobol.Error {
+
+	if ifMatch := r.Header.Get(headerIfMatch); ifMatch != "" {
+		if etag == "" || !matchETag(ifMatch, etag, true) {
+			return errPreconditionFailed()
+		}
+		return nil
+	}
+
+	if lastModified.IsZero() {
+		return nil
+	}
+
+	since, err := http.ParseTime(r.Header.Get(headerIfUnmodifiedSince))
+	if err != nil {
+		return nil
+	}
+
+	if lastModified.Truncate(time.Second).After(since) {
+		return errPreconditionFailed()
+	}
+
+	return nil
+}
+
+// errPreconditionFailed - returns the 412 error
+func errPreconditionFailed() gobol.Error {
+	return errBasic("rip", "CheckPreconditions", msgPreconditionFailed, http.StatusPreconditionFailed, errors.New(http.StatusText(http.StatusPreconditionFailed)))
+}
+
+// matchETag - checks if the entity tag is in the header list, the strong comparison never matches weak tags
+func matchETag(header, etag string, strong bool) bool {
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	if strong && strings.HasPrefix(etag, weakPrefix) {
+		return false
+	}
+
+	opaque := strings.TrimPrefix(etag, weakPrefix)
+
+	for _, candidate := range splitETags(header) {
+
+		if strong && strings.HasPrefix(candidate, weakPrefix) {
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, weakPrefix) == opaque {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitETags - splits a list of entity tags, which may contain commas inside the quotes
+func splitETags(header string) []string {
+
+	etags := []string{}
+
+	for {
+
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			return etags
+		}
+
+		start := 0
+		if strings.HasPrefix(header, weakPrefix) {
+			start = len(weakPrefix)
+		}
+
+		if len(header) <= start || header[start] != '"' {
+			return etags
+		}
+
+		end := strings.IndexByte(header[start+1:], '"')
+		if end < 0 {
+			return etags
+		}
+
+		end += start + 2
+		etags = append(etags, header[:end])
+		header = header[end:]
+	}
+}
+
+// SuccessWithETag - same as Success, setting the body entity tag and answering 304 when the client copy is current
+func SuccessWithETag(w http.ResponseWriter, r *http.Request, statusCode int, payload []byte, weak bool) {
+
+	etag := StrongETag(payload)
+	if weak {
+		etag = WeakETag(payload)
+	}
+
+	if NotModified(w, r, etag, time.Time{}) {
+		return
+	}
+
+	Success(w, statusCode, payload)
+}
+
+// SuccessJSONWithETag - same as SuccessJSON, setting the body entity tag and answering 304 when the client copy is current
+func SuccessJSONWithETag(w http.ResponseWriter, r *http.Request, statusCode int, payload interface{}, weak bool) {
+
+	b, err := jsonMarshaller.Marshal(payload)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set(headerContentType, mediaTypeJSON)
+
+	SuccessWithETag(w, r, statusCode, b, weak)
+}
@@ -0,0 +1,88 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessJSONWithETag(t *testing.T) {
+
+	payload := map[string]int{"news": 10}
+
+	w := httptest.NewRecorder()
+	SuccessJSONWithETag(w, httptest.NewRequest(http.MethodGet, "/facets", nil), http.StatusOK, payload, false)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	etag := w.Header().Get(headerETag)
+	assert.NotEmpty(t, etag)
+
+	r := httptest.NewRequest(http.MethodGet, "/facets", nil)
+	r.Header.Set(headerIfNoneMatch, `"other", `+weakPrefix+etag)
+
+	w = httptest.NewRecorder()
+	SuccessJSONWithETag(w, r, http.StatusOK, payload, false)
+	assert.Equal(t, http.StatusNotModified, w.Code, "weak comparison must match")
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get(headerETag))
+	assert.Empty(t, w.Header().Get("Content-Type"))
+
+	r.Header.Set(headerIfNoneMatch, `"other"`)
+
+	w = httptest.NewRecorder()
+	SuccessJSONWithETag(w, r, http.StatusOK, payload, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, weakPrefix+etag, w.Header().Get(headerETag))
+}
+
+func TestNotModifiedSince(t *testing.T) {
+
+	modified := time.Date(2020, 6, 1, 12, 0, 0, 500, time.UTC)
+
+	r := httptest.NewRequest(http.MethodGet, "/facets", nil)
+	r.Header.Set(headerIfModifiedSince, modified.Format(http.TimeFormat))
+
+	w := httptest.NewRecorder()
+	assert.True(t, NotModified(w, r, "", modified))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = httptest.NewRecorder()
+	assert.False(t, NotModified(w, r, "", modified.Add(time.Second)))
+	assert.Equal(t, modified.Add(time.Second).Format(http.TimeFormat), w.Header().Get(headerLastModified))
+
+	r.Header.Set(headerIfNoneMatch, `"v2"`)
+	assert.False(t, NotModified(httptest.NewRecorder(), r, `"v1"`, modified), "If-None-Match takes precedence")
+
+	r.Method = http.MethodPost
+	assert.False(t, NotModified(httptest.NewRecorder(), r, `"v2"`, modified), "only GET and HEAD")
+}
+
+func TestCheckPreconditions(t *testing.T) {
+
+	r := httptest.NewRequest(http.MethodPut, "/documents/1", nil)
+	assert.Nil(t, CheckPreconditions(r, VersionETag("3", false), time.Time{}))
+
+	r.Header.Set(headerIfMatch, `"2", "3"`)
+	assert.Nil(t, CheckPreconditions(r, VersionETag("3", false), time.Time{}))
+
+	gerr := CheckPreconditions(r, VersionETag("4", false), time.Time{})
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusPreconditionFailed, gerr.StatusCode())
+	}
+
+	assert.NotNil(t, CheckPreconditions(r, VersionETag("3", true), time.Time{}), "strong comparison never matches weak tags")
+
+	r.Header.Set(headerIfMatch, "*")
+	assert.Nil(t, CheckPreconditions(r, `"4"`, time.Time{}))
+	assert.NotNil(t, CheckPreconditions(r, "", time.Time{}), "the resource does not exist")
+
+	modified := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	r = httptest.NewRequest(http.MethodPut, "/documents/1", nil)
+	r.Header.Set(headerIfUnmodifiedSince, modified.Format(http.TimeFormat))
+	assert.Nil(t, CheckPreconditions(r, "", modified))
+	assert.NotNil(t, CheckPreconditions(r, "", modified.Add(time.Minute)))
+}
@@ -0,0 +1,281 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+)
+
+const (
+	healthUp   = "up"
+	healthDown = "down"
+
+	defaultHealthTimeout = 2 * time.Second
+)
+
+// HealthChecker - checks the health of a component
+type HealthChecker interface {
+
+	// Name - the component name
+	Name() string
+
+	// Check - returns an error if the component is not healthy
+	Check(ctx context.Context) error
+}
+
+// checkerFunc - adapts a function to the HealthChecker interface
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c *checkerFunc) Name() string {
+	return c.name
+}
+
+func (c *checkerFunc) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// NewCheckerFunc - creates a health checker from a function
+func NewCheckerFunc(name string, check func(ctx context.Context) error) HealthChecker {
+
+	return &checkerFunc{
+		name:  name,
+		check: check,
+	}
+}
+
+// ComponentHealth - the health of a component
+type ComponentHealth struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport - the aggregated health
+type HealthReport struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components,omitempty"`
+}
+
+// HealthConfiguration - configures the health endpoints
+type HealthConfiguration struct {
+
+	// Timeout - the maximum duration of each check (2 seconds by default)
+	Timeout funks.Duration
+
+	// CacheDuration - the duration the last report is reused (no caching when zero)
+	CacheDuration funks.Duration
+}
+
+// healthCall - a report in progress, the concurrent callers wait for its result
+type healthCall struct {
+	done   chan struct{}
+	report *HealthReport
+}
+
+// Health - aggregates the registered checkers in the liveness and readiness endpoints
+type Health struct {
+	timeout   time.Duration
+	cacheTTL  time.Duration
+	checkers  []HealthChecker
+	readiness func() bool
+	mutex     sync.Mutex
+	cached    *HealthReport
+	cachedAt  time.Time
+	call      *healthCall
+	version   int
+}
+
+// NewHealth - creates a new health aggregator
+func NewHealth(configuration *HealthConfiguration) *Health {
+
+	if configuration == nil {
+		configuration = &HealthConfiguration{}
+	}
+
+	timeout := configuration.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	return &Health{
+		timeout:  timeout,
+		cacheTTL: configuration.CacheDuration.Duration,
+	}
+}
+
+// Register - adds checkers to the readiness report
+func (h *Health) Register(checkers ...HealthChecker) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.checkers = append(h.checkers, checkers...)
+	h.cached = nil
+	h.version++
+}
+
+// SetReadiness - sets a function toggling the readiness, like Server.Ready during the shutdown
+func (h *Health) SetReadiness(readiness func() bool) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.readiness = readiness
+}
+
+// check - runs a checker respecting the timeout, the context is not tied to a request because the report is shared
+func (h *Health) check(checker HealthChecker) ComponentHealth {
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+
+	go func() {
+		result <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = errors.New("timeout")
+	}
+
+	component := ComponentHealth{
+		Name:      checker.Name(),
+		Status:    healthUp,
+		LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+
+	if err != nil {
+		component.Status = healthDown
+		component.Error = err.Error()
+	}
+
+	return component
+}
+
+// Report - runs all checkers concurrently (or returns the cached report), concurrent callers share the same run
+// and the context only limits how long the caller waits for it
+func (h *Health) Report(ctx context.Context) *HealthReport {
+
+	h.mutex.Lock()
+
+	if h.cached != nil && time.Since(h.cachedAt) < h.cacheTTL {
+		report := h.cached
+		h.mutex.Unlock()
+		return report
+	}
+
+	call := h.call
+	if call == nil {
+		call = &healthCall{
+			done: make(chan struct{}),
+		}
+		h.call = call
+		go h.run(call, append([]HealthChecker(nil), h.checkers...), h.version)
+	}
+
+	h.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.report
+	case <-ctx.Done():
+		return &HealthReport{Status: healthDown}
+	}
+}
+
+// run - runs the checkers without holding the lock and publishes the report
+func (h *Health) run(call *healthCall, checkers []HealthChecker, version int) {
+
+	report := &HealthReport{
+		Status:     healthUp,
+		Components: make([]ComponentHealth, len(checkers)),
+	}
+
+	wg := sync.WaitGroup{}
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker HealthChecker) {
+			defer wg.Done()
+			report.Components[i] = h.check(checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status != healthUp {
+			report.Status = healthDown
+			break
+		}
+	}
+
+	h.mutex.Lock()
+
+	h.call = nil
+	if version == h.version {
+		h.cached = report
+		h.cachedAt = time.Now()
+	}
+
+	h.mutex.Unlock()
+
+	call.report = report
+	close(call.done)
+}
+
+// LiveHandler - answers 200 while the process is able to serve
+func (h *Health) LiveHandler() HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		SuccessJSON(w, http.StatusOK, &HealthReport{Status: healthUp})
+		return nil
+	}
+}
+
+// ReadyHandler - answers 200 when all checkers are up and 503 otherwise
+func (h *Health) ReadyHandler() HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+
+		h.mutex.Lock()
+		readiness := h.readiness
+		h.mutex.Unlock()
+
+		if readiness != nil && !readiness() {
+			SuccessJSON(w, http.StatusServiceUnavailable, &HealthReport{Status: healthDown})
+			return nil
+		}
+
+		report := h.Report(r.Context())
+
+		status := http.StatusOK
+		if report.Status != healthUp {
+			status = http.StatusServiceUnavailable
+		}
+
+		SuccessJSON(w, status, report)
+
+		return nil
+	}
+}
+
+// Mount - registers the /health/live and /health/ready endpoints on the router
+func (h *Health) Mount(router *Router) {
+
+	router.GET("/health/live", h.LiveHandler(), Name("health.live"), Authorization(&AuthorizationRule{Public: true}))
+	router.GET("/health/ready", h.ReadyHandler(), Name("health.ready"), Authorization(&AuthorizationRule{Public: true}))
+}
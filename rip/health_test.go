@@ -0,0 +1,100 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+)
+
+func TestHealthReport(t *testing.T) {
+
+	health := NewHealth(&HealthConfiguration{Timeout: funks.Duration{Duration: 50 * time.Millisecond}})
+
+	health.Register(
+		NewCheckerFunc("db", func(ctx context.Context) error { return nil }),
+		NewCheckerFunc("queue", func(ctx context.Context) error { return errors.New("unreachable") }),
+		NewCheckerFunc("slow", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }),
+	)
+
+	report := health.Report(context.Background())
+
+	assert.Equal(t, healthDown, report.Status)
+	if assert.Len(t, report.Components, 3) {
+		assert.Equal(t, healthUp, report.Components[0].Status)
+		assert.Equal(t, "unreachable", report.Components[1].Error)
+		assert.Equal(t, "timeout", report.Components[2].Error)
+	}
+}
+
+func TestHealthConcurrentReports(t *testing.T) {
+
+	health := NewHealth(&HealthConfiguration{CacheDuration: funks.Duration{Duration: time.Minute}})
+
+	var calls int32
+	release := make(chan struct{})
+	var checkErr atomic.Value
+
+	health.Register(NewCheckerFunc("db", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		if ctx.Err() != nil {
+			checkErr.Store(ctx.Err())
+		}
+		return nil
+	}))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, healthDown, health.Report(canceled).Status, "the caller stops waiting when its context is done")
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, healthUp, health.Report(context.Background()).Status)
+		}()
+	}
+
+	ready := make(chan struct{})
+	go func() {
+		defer close(ready)
+		health.ReadyHandler()(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health/ready", nil), nil)
+	}()
+
+	close(release)
+	wg.Wait()
+	<-ready
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "concurrent reports must share the same run")
+	assert.Nil(t, checkErr.Load(), "a canceled caller must not cancel the shared checks")
+
+	health.Report(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "the report must be cached")
+}
+
+func TestHealthReadiness(t *testing.T) {
+
+	health := NewHealth(nil)
+	health.Register(NewCheckerFunc("db", func(ctx context.Context) error { return nil }))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		health.ReadyHandler()(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil), nil)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve())
+
+	ready := false
+	health.SetReadiness(func() bool { return ready })
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+}
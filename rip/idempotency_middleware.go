@@ -0,0 +1,406 @@
+package rip
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+)
+
+const (
+	metricIdempotencyReplayed string = "http.idempotency.replayed"
+	metricIdempotencyConflict string = "http.idempotency.conflict"
+
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	msgIdempotencyInFlight    = "a request with the same idempotency key is in progress"
+	msgIdempotencyMismatch    = "the idempotency key was used with a different request"
+	msgIdempotencyKeyRequired = "the Idempotency-Key header is required"
+	msgIdempotencyKeyInvalid  = "invalid Idempotency-Key header"
+
+	defaultIdempotencyTTL             = 24 * time.Hour
+	defaultIdempotencyLockTimeout     = time.Minute
+	defaultIdempotencyMaxBodySize     = 10 << 20
+	defaultIdempotencyMaxResponseSize = 1 << 20
+)
+
+// idempotentResponse - a response stored by idempotency key, the stores keep it encoded as JSON
+type idempotentResponse struct {
+
+	// Fingerprint - the hash of the request method, path and body
+	Fingerprint string `json:"fingerprint"`
+
+	// Status - the response status code
+	Status int `json:"status"`
+
+	// Header - the response headers set by the handler
+	Header http.Header `json:"header,omitempty"`
+
+	// Body - the response body
+	Body []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore - stores the encoded responses by idempotency key, it must be safe for concurrent use by many instances
+type IdempotencyStore interface {
+
+	// Begin - reserves the key for lockTimeout returning true, or returns the completed response or nil if another request holds it
+	Begin(ctx context.Context, key string, lockTimeout time.Duration) ([]byte, bool, error)
+
+	// Complete - stores the response of the reserved key for ttl, failing if the reservation was lost
+	Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error
+
+	// Release - removes the reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyConfiguration - configures the idempotency middleware
+type IdempotencyConfiguration struct {
+
+	// TTL - how long the responses are replayed (24 hours by default)
+	TTL funks.Duration
+
+	// LockTimeout - how long a request in flight holds the key (1 minute by default)
+	LockTimeout funks.Duration
+
+	// Methods - the methods handled (POST and PATCH by default)
+	Methods []string
+
+	// Required - rejects the requests without the Idempotency-Key header
+	Required bool
+
+	// MaxBodySize - the maximum request body size in bytes (10MB by default)
+	MaxBodySize int64
+
+	// MaxResponseSize - requests with larger response bodies release the key instead of being stored (1MB by default)
+	MaxResponseSize int
+}
+
+// IdempotencyHandler - replays the responses of requests retried with the same Idempotency-Key header
+type IdempotencyHandler struct {
+	next            http.Handler
+	store           IdempotencyStore
+	ttl             time.Duration
+	lockTimeout     time.Duration
+	methods         map[string]struct{}
+	required        bool
+	maxBodySize     int64
+	maxResponseSize int
+	stats           StatisticsInterface
+}
+
+// NewIdempotencyMiddleware - creates a new instance of IdempotencyHandler, wrap a single route handler to configure it per route (statisticsImpl can be nil)
+func NewIdempotencyMiddleware(next http.Handler, store IdempotencyStore, configuration *IdempotencyConfiguration, statisticsImpl StatisticsInterface) (*IdempotencyHandler, error) {
+
+	if store == nil {
+		return nil, errors.New("null store")
+	}
+
+	if configuration == nil {
+		configuration = &IdempotencyConfiguration{}
+	}
+
+	h := &IdempotencyHandler{
+		next:            next,
+		store:           store,
+		ttl:             configuration.TTL.Duration,
+		lockTimeout:     configuration.LockTimeout.Duration,
+		methods:         map[string]struct{}{},
+		required:        configuration.Required,
+		maxBodySize:     configuration.MaxBodySize,
+		maxResponseSize: configuration.MaxResponseSize,
+		stats:           statisticsImpl,
+	}
+
+	if h.ttl <= 0 {
+		h.ttl = defaultIdempotencyTTL
+	}
+
+	if h.lockTimeout <= 0 {
+		h.lockTimeout = defaultIdempotencyLockTimeout
+	}
+
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	if h.maxResponseSize <= 0 {
+		h.maxResponseSize = defaultIdempotencyMaxResponseSize
+	}
+
+	methods := configuration.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	for _, method := range methods {
+		h.methods[strings.ToUpper(method)] = struct{}{}
+	}
+
+	return h, nil
+}
+
+// validIdempotencyKey - checks if the key is printable and not too long
+func validIdempotencyKey(key string) bool {
+
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fingerprint - hashes the request method, path and body
+func fingerprint(r *http.Request, body []byte) string {
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// increment - increments the metric if there is a statistics implementation
+func (h *IdempotencyHandler) increment(metric string, r *http.Request) {
+
+	if h.stats != nil {
+		h.stats.Increment(metric, tagMethod, r.Method, tagPath, routePattern(r, nil))
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *IdempotencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if _, ok := h.methods[r.Method]; !ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	idempotencyKey := r.Header.Get(headerIdempotencyKey)
+	if idempotencyKey == "" {
+		if h.required {
+			FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgIdempotencyKeyRequired, http.StatusBadRequest, errors.New(msgIdempotencyKeyRequired)))
+			return
+		}
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if !validIdempotencyKey(idempotencyKey) {
+		FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgIdempotencyKeyInvalid, http.StatusBadRequest, errors.New(msgIdempotencyKeyInvalid)))
+		return
+	}
+
+	var body []byte
+
+	if r.Body != nil {
+
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, h.maxBodySize+1))
+		r.Body.Close()
+		if err != nil {
+			FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", "error reading the body", http.StatusBadRequest, err))
+			return
+		}
+
+		if int64(len(body)) > h.maxBodySize {
+			FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgPayloadTooLarge, http.StatusRequestEntityTooLarge, errors.New(http.StatusText(http.StatusRequestEntityTooLarge))))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	// the keys are scoped by client when the request is authenticated
+	key := idempotencyKey
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		key = principal.ID + ":" + idempotencyKey
+	}
+
+	digest := fingerprint(r, body)
+
+	stored, acquired, err := h.store.Begin(r.Context(), key, h.lockTimeout)
+	if err != nil {
+		FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", "idempotency store unavailable", http.StatusServiceUnavailable, err))
+		return
+	}
+
+	if !acquired {
+		h.replay(w, r, stored, digest)
+		return
+	}
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			h.store.Release(context.Background(), key)
+		}
+	}()
+
+	recorder := newCacheResponseWriter(w, h.maxResponseSize)
+
+	h.next.ServeHTTP(recorder, r)
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+		recorder.header = recorder.handlerHeaders()
+	}
+
+	if recorder.status >= http.StatusInternalServerError || recorder.exceeded {
+		return
+	}
+
+	response, err := json.Marshal(&idempotentResponse{
+		Fingerprint: digest,
+		Status:      recorder.status,
+		Header:      recorder.header,
+		Body:        recorder.body,
+	})
+	if err != nil {
+		logError(r.Context(), errBasic("rip", "ServeHTTP", "error encoding the idempotent response", http.StatusInternalServerError, err))
+		return
+	}
+
+	if err := h.store.Complete(context.Background(), key, response, h.ttl); err != nil {
+		logError(r.Context(), errBasic("rip", "ServeHTTP", "error storing the idempotent response", http.StatusInternalServerError, err))
+		return
+	}
+
+	completed = true
+}
+
+// replay - writes the stored response, or the conflict error if the request is in flight or differs
+func (h *IdempotencyHandler) replay(w http.ResponseWriter, r *http.Request, encoded []byte, digest string) {
+
+	if encoded == nil {
+		h.increment(metricIdempotencyConflict, r)
+		FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgIdempotencyInFlight, http.StatusConflict, errors.New(http.StatusText(http.StatusConflict))))
+		return
+	}
+
+	stored := &idempotentResponse{}
+	if err := json.Unmarshal(encoded, stored); err != nil {
+		FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", "invalid idempotent response", http.StatusInternalServerError, err))
+		return
+	}
+
+	if stored.Fingerprint != digest {
+		FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgIdempotencyMismatch, http.StatusUnprocessableEntity, errors.New(http.StatusText(http.StatusUnprocessableEntity))))
+		return
+	}
+
+	h.increment(metricIdempotencyReplayed, r)
+
+	header := w.Header()
+	for k, v := range stored.Header {
+		header[k] = v
+	}
+
+	header.Set(headerIdempotentReplayed, "true")
+
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// errIdempotencyReservationLost - returned when completing a key whose reservation expired
+var errIdempotencyReservationLost = errors.New("idempotency key reservation lost")
+
+// memoryIdempotencyEntry - a key reservation or a completed response
+type memoryIdempotencyEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+// MemoryIdempotencyStore - an in memory IdempotencyStore, suitable for a single instance
+type MemoryIdempotencyStore struct {
+	mutex     sync.Mutex
+	entries   map[string]*memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore - creates a new in memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+
+	return &MemoryIdempotencyStore{
+		entries:   map[string]*memoryIdempotencyEntry{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Begin - implements the IdempotencyStore interface
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, key string, lockTimeout time.Duration) ([]byte, bool, error) {
+
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastSweep) > lockTimeout {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e.response, false, nil
+	}
+
+	s.entries[key] = &memoryIdempotencyEntry{
+		expires: now.Add(lockTimeout),
+	}
+
+	return nil, true, nil
+}
+
+// Complete - implements the IdempotencyStore interface
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, ok := s.entries[key]; !ok || e.response != nil || now.After(e.expires) {
+		return errIdempotencyReservationLost
+	}
+
+	s.entries[key] = &memoryIdempotencyEntry{
+		response: response,
+		expires:  now.Add(ttl),
+	}
+
+	return nil
+}
+
+// Release - implements the IdempotencyStore interface
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
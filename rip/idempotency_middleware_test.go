@@ -0,0 +1,113 @@
+package rip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	store := NewMemoryIdempotencyStore()
+
+	var calls int32
+	block := make(chan struct{})
+
+	h, err := NewIdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-block
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/large" {
+			Success(w, http.StatusCreated, []byte(strings.Repeat("x", 64)))
+			return
+		}
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-Call", string(rune('0'+n)))
+		Success(w, http.StatusCreated, []byte("created"))
+	}), store, &IdempotencyConfiguration{MaxResponseSize: 32}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	outer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRequestID, r.Header.Get("X-Test-ID"))
+		h.ServeHTTP(w, r)
+	})
+
+	var requests int32
+
+	serve := func(method, path, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(headerIdempotencyKey, key)
+		}
+		r.Header.Set("X-Test-ID", string(rune('a'+atomic.AddInt32(&requests, 1))))
+		w := httptest.NewRecorder()
+		outer.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/documents", "k1", `{"id":1}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(headerIdempotentReplayed))
+
+	w = serve(http.MethodPost, "/documents", "k1", `{"id":1}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "created", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Call"))
+	assert.Equal(t, "true", w.Header().Get(headerIdempotentReplayed))
+	assert.Equal(t, []string{"c"}, w.Header().Values(headerRequestID), "headers of outer middlewares must not be replayed")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, []interface{}{tagMethod, http.MethodPost, tagPath, strUndefined}, stats.tags[metricIdempotencyReplayed], "the raw path must not be a tag")
+
+	w = serve(http.MethodPost, "/documents", "k1", `{"id":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "the key was used with another body")
+
+	serve(http.MethodPost, "/documents", "", `{"id":1}`)
+	serve(http.MethodPut, "/documents", "k1", `{"id":1}`)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "requests without key or with other methods are not handled")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, http.StatusCreated, serve(http.MethodPost, "/slow", "k2", "").Code)
+	}()
+
+	for inFlight := false; !inFlight; {
+		store.mutex.Lock()
+		_, inFlight = store.entries["k2"]
+		store.mutex.Unlock()
+	}
+
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/slow", "k2", "").Code)
+	close(block)
+	<-done
+
+	assert.Equal(t, 1, stats.increments[metricIdempotencyConflict])
+
+	assert.Equal(t, http.StatusInternalServerError, serve(http.MethodPost, "/fail", "k3", "").Code)
+	_, acquired, _ := store.Begin(context.Background(), "k3", h.lockTimeout)
+	assert.True(t, acquired, "failed requests must release the key")
+
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost, "/large", "k5", "").Code)
+	_, acquired, _ = store.Begin(context.Background(), "k5", h.lockTimeout)
+	assert.True(t, acquired, "responses larger than the limit must release the key")
+
+	assert.Error(t, store.Complete(context.Background(), "k6", []byte("{}"), h.ttl), "keys not reserved can not be completed")
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/documents", "k\n4", "").Code)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/uol/gobol"
 	"github.com/uol/logh"
 )
 
@@ -84,6 +85,26 @@ type StatisticsInterface interface {
 	Maximum(metric string, value float64, tags ...interface{})
 }
 
+// withStatsTags - installs a holder for the tags added by the handlers in the request context if there is none
+func withStatsTags(r *http.Request) (*http.Request, *[]interface{}) {
+
+	if tags, ok := r.Context().Value(statsTagskey).(*[]interface{}); ok {
+		return r, tags
+	}
+
+	tags := &[]interface{}{}
+
+	return r.WithContext(context.WithValue(r.Context(), statsTagskey, tags)), tags
+}
+
+// AddStatsTags - adds tag pairs to the request metrics, a "path" tag replaces the request path
+func AddStatsTags(r *http.Request, tags ...interface{}) {
+
+	if holder, ok := r.Context().Value(statsTagskey).(*[]interface{}); ok {
+		*holder = append(*holder, tags...)
+	}
+}
+
 // ServeHTTP - implements the interface to serve http requests
 func (h *LogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
@@ -95,7 +116,7 @@ func (h *LogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ResponseWriter: w,
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 
 	var userTags []interface{}
 
@@ -164,7 +185,7 @@ func (h *LogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.stats.Maximum(metricResponseSize, (float64)(logResponseWriter.size), tags...)
 	} else {
 		if logh.WarnEnabled {
-			h.logger.Warn().Msgf("received a wrong number of tags: %+v", userTags...)
+			gobol.LogWithRequestID(r.Context(), h.logger.Warn()).Msgf("received a wrong number of tags: %+v", userTags...)
 		}
 	}
 }
@@ -0,0 +1,35 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMiddlewareStatsTags(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	h := NewLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}), 8080, stats)
+
+	serve := func(target string) []interface{} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		stats.mutex.Lock()
+		defer stats.mutex.Unlock()
+		return stats.tags[metricRequestCount]
+	}
+
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagStatus, "200", tagPath, "/documents/1"}, serve("/documents/1?fields=id"), "the query string is not part of the path")
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagStatus, "404", tagPath, strUndefined}, serve("/missing"))
+}
@@ -0,0 +1,492 @@
+package rip
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/gobol"
+)
+
+const (
+	openAPIVersion     = "3.0.3"
+	schemaRefPrefix    = "#/components/schemas/"
+	errorSchemaName    = "Error"
+	mediaTypeJSON      = "application/json"
+	validateTag        = "validate"
+	defaultSuccessCode = http.StatusOK
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// RouteDoc - the route documentation used to generate the OpenAPI document
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     reflect.Type
+	Responses   map[int]reflect.Type
+	Hidden      bool
+}
+
+// doc - returns the route documentation, creating it if needed
+func (route *Route) doc() *RouteDoc {
+
+	if route.Doc == nil {
+		route.Doc = &RouteDoc{
+			Responses: map[int]reflect.Type{},
+		}
+	}
+
+	return route.Doc
+}
+
+// Describe - documents the route summary and description
+func Describe(summary, description string, tags ...string) RouteOption {
+	return func(route *Route) {
+		doc := route.doc()
+		doc.Summary = summary
+		doc.Description = description
+		doc.Tags = append(doc.Tags, tags...)
+	}
+}
+
+// Request - documents the request body type using a value of it
+func Request(body interface{}) RouteOption {
+	return func(route *Route) {
+		route.doc().Request = reflect.TypeOf(body)
+	}
+}
+
+// Response - documents a response status and body type using a value of it (nil for no body)
+func Response(status int, body interface{}) RouteOption {
+	return func(route *Route) {
+		route.doc().Responses[status] = reflect.TypeOf(body)
+	}
+}
+
+// Hidden - hides the route from the OpenAPI document
+func Hidden() RouteOption {
+	return func(route *Route) {
+		route.doc().Hidden = true
+	}
+}
+
+// OpenAPIInfo - the document information
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIDocument - an OpenAPI 3 document
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIComponents - the reusable schemas
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// OpenAPIOperation - an operation of a path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter - a path or query parameter
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIBody - a request body
+type OpenAPIBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse - a response
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType - the schema of a media type
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema - a JSON schema subset
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// schemaBuilder - builds the schemas registering the named structs as components
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+// schemaName - returns the component name of a named type
+func schemaName(t reflect.Type) string {
+
+	name := t.Name()
+	if pkg := t.PkgPath(); pkg != "" {
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+
+	return name
+}
+
+// build - returns the schema of the type
+func (b *schemaBuilder) build(t reflect.Type) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return &Schema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+		}
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.build(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.build(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := b.schemas[name]; !ok {
+			// registered before building to support recursive types
+			b.schemas[name] = &Schema{}
+			*b.schemas[name] = *b.object(t)
+		}
+		return &Schema{Ref: schemaRefPrefix + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// object - builds the schema of a struct using the json and validate tags
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	b.addFields(schema, t)
+
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+// addFields - adds the struct fields to the schema, flattening the embedded structs
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		name, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(schema, ft)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.build(field.Type)
+
+		if applyValidation(property, field.Tag.Get(validateTag)) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		if field.Type.Kind() == reflect.Ptr && property.Ref == "" {
+			property.Nullable = true
+		}
+
+		if description := field.Tag.Get("description"); description != "" && property.Ref == "" {
+			property.Description = description
+		}
+
+		schema.Properties[name] = property
+	}
+}
+
+// jsonFieldName - returns the field name from the json tag and if it must be skipped
+func jsonFieldName(field reflect.StructField) (string, bool) {
+
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	return strings.Split(tag, ",")[0], false
+}
+
+// applyValidation - maps the validate tag rules to the schema, returning if the field is required
+func applyValidation(schema *Schema, tag string) bool {
+
+	if tag == "" {
+		return false
+	}
+
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+
+		key, value := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, value = rule[:i], rule[i+1:]
+		}
+
+		switch key {
+		case "required":
+			required = true
+		case "min", "gte":
+			setLimit(schema, value, true)
+		case "max", "lte":
+			setLimit(schema, value, false)
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		}
+	}
+
+	return required
+}
+
+// setLimit - sets the lower or upper limit according to the schema type
+func setLimit(schema *Schema, value string, lower bool) {
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	length := int(number)
+
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &length
+		} else {
+			schema.MaxItems = &length
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &number
+		} else {
+			schema.Maximum = &number
+		}
+	}
+}
+
+// openAPIPath - converts the router path to the OpenAPI format, returning the path parameters
+func openAPIPath(path string) (string, []string) {
+
+	segments := strings.Split(path, "/")
+	params := []string{}
+
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// jsonContent - returns the JSON content of a schema
+func jsonContent(schema *Schema) map[string]*OpenAPIMediaType {
+	return map[string]*OpenAPIMediaType{
+		mediaTypeJSON: {Schema: schema},
+	}
+}
+
+// OpenAPI - generates the OpenAPI document of the registered routes
+func (r *Router) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+
+	b := &schemaBuilder{
+		schemas: map[string]*Schema{},
+	}
+
+	b.schemas[errorSchemaName] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error":   {Type: "string"},
+			"message": {Type: "string"},
+			"fields":  b.build(reflect.TypeOf([]FieldError{})),
+		},
+	}
+
+	document := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    info,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{
+			Schemas: b.schemas,
+		},
+	}
+
+	errorResponse := &OpenAPIResponse{
+		Description: "error",
+		Content:     jsonContent(&Schema{Ref: schemaRefPrefix + errorSchemaName}),
+	}
+
+	for _, route := range r.Routes() {
+
+		doc := route.Doc
+		if doc == nil {
+			doc = &RouteDoc{}
+		}
+
+		if doc.Hidden {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+
+		operation := &OpenAPIOperation{
+			OperationID: route.Name,
+			Summary:     doc.Summary,
+			Description: doc.Description,
+			Tags:        doc.Tags,
+			Responses:   map[string]*OpenAPIResponse{},
+		}
+
+		for _, param := range params {
+			operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+				Name:     param,
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+
+		if doc.Request != nil {
+			operation.RequestBody = &OpenAPIBody{
+				Required: true,
+				Content:  jsonContent(b.build(doc.Request)),
+			}
+		}
+
+		for status, body := range doc.Responses {
+			response := &OpenAPIResponse{
+				Description: http.StatusText(status),
+			}
+			if body != nil {
+				response.Content = jsonContent(b.build(body))
+			}
+			operation.Responses[strconv.Itoa(status)] = response
+		}
+
+		if len(operation.Responses) == 0 {
+			operation.Responses[strconv.Itoa(defaultSuccessCode)] = &OpenAPIResponse{
+				Description: http.StatusText(defaultSuccessCode),
+			}
+		}
+
+		operation.Responses["default"] = errorResponse
+
+		if _, ok := document.Paths[path]; !ok {
+			document.Paths[path] = map[string]*OpenAPIOperation{}
+		}
+
+		document.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	return document
+}
+
+// ServeOpenAPI - registers a route serving the OpenAPI document generated on each request
+func (r *Router) ServeOpenAPI(path string, info OpenAPIInfo, options ...RouteOption) *Route {
+
+	options = append(options, Hidden(), Authorization(&AuthorizationRule{Public: true}))
+
+	return r.GET(path, func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) gobol.Error {
+		SuccessJSON(w, http.StatusOK, r.OpenAPI(info))
+		return nil
+	}, options...)
+}
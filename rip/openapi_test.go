@@ -0,0 +1,101 @@
+package rip
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+type openAPIAuthor struct {
+	Name  string `json:"name" validate:"required,max=64"`
+	Email string `json:"email,omitempty" validate:"email"`
+}
+
+type openAPIArticle struct {
+	Title     string            `json:"title" validate:"required,min=3"`
+	Status    string            `json:"status" validate:"oneof=draft published"`
+	Rating    *int              `json:"rating,omitempty" validate:"min=0,max=5"`
+	Tags      []string          `json:"tags"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Author    openAPIAuthor     `json:"author"`
+	Published time.Time         `json:"published"`
+	internal  string
+	Ignored   string `json:"-"`
+}
+
+func TestOpenAPI(t *testing.T) {
+
+	router := NewRouter()
+
+	noop := func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		return nil
+	}
+
+	router.POST("/articles/:id", noop,
+		Name("createArticle"),
+		Describe("creates an article", "", "articles"),
+		Request(openAPIArticle{}),
+		Response(http.StatusCreated, &openAPIArticle{}),
+		Response(http.StatusNoContent, nil),
+	)
+	router.GET("/internal", noop, Hidden())
+	router.ServeOpenAPI("/openapi.json", OpenAPIInfo{Title: "articles", Version: "1.0.0"})
+
+	w := serveRouter(router, http.MethodGet, "/openapi.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	document := OpenAPIDocument{}
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &document)) {
+		return
+	}
+
+	assert.Equal(t, openAPIVersion, document.OpenAPI)
+	assert.Len(t, document.Paths, 1, "hidden and openapi routes must not be documented")
+
+	operation := document.Paths["/articles/{id}"]["post"]
+	if !assert.NotNil(t, operation) {
+		return
+	}
+
+	assert.Equal(t, "createArticle", operation.OperationID)
+	assert.Equal(t, []string{"articles"}, operation.Tags)
+	assert.Equal(t, "id", operation.Parameters[0].Name)
+	assert.Equal(t, "path", operation.Parameters[0].In)
+	assert.Equal(t, "#/components/schemas/rip.openAPIArticle", operation.RequestBody.Content[mediaTypeJSON].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/rip.openAPIArticle", operation.Responses["201"].Content[mediaTypeJSON].Schema.Ref)
+	assert.Nil(t, operation.Responses["204"].Content)
+	assert.Equal(t, "#/components/schemas/Error", operation.Responses["default"].Content[mediaTypeJSON].Schema.Ref)
+
+	if errorSchema := document.Components.Schemas[errorSchemaName]; assert.NotNil(t, errorSchema) {
+		assert.Equal(t, "#/components/schemas/rip.FieldError", errorSchema.Properties["fields"].Items.Ref, "the error model must document the field errors")
+		assert.Len(t, document.Components.Schemas["rip.FieldError"].Properties, 3)
+	}
+
+	article := document.Components.Schemas["rip.openAPIArticle"]
+	if !assert.NotNil(t, article) {
+		return
+	}
+
+	assert.Equal(t, []string{"title"}, article.Required)
+	assert.Len(t, article.Properties, 7)
+	assert.Equal(t, 3, *article.Properties["title"].MinLength)
+	assert.Equal(t, []string{"draft", "published"}, article.Properties["status"].Enum)
+	assert.Equal(t, 5.0, *article.Properties["rating"].Maximum)
+	assert.True(t, article.Properties["rating"].Nullable)
+	assert.Equal(t, "array", article.Properties["tags"].Type)
+	assert.Equal(t, "string", article.Properties["metadata"].AdditionalProperties.Type)
+	assert.Equal(t, "date-time", article.Properties["published"].Format)
+	assert.Equal(t, "#/components/schemas/rip.openAPIAuthor", article.Properties["author"].Ref)
+
+	author := document.Components.Schemas["rip.openAPIAuthor"]
+	if assert.NotNil(t, author) {
+		assert.Equal(t, []string{"name"}, author.Required)
+		assert.Equal(t, "email", author.Properties["email"].Format)
+		assert.Equal(t, 64, *author.Properties["name"].MaxLength)
+	}
+}
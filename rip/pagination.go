@@ -0,0 +1,303 @@
+package rip
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+)
+
+const (
+	paramPage   = "page"
+	paramLimit  = "limit"
+	paramCursor = "cursor"
+	headerLink  = "Link"
+
+	defaultPageLimit    = 20
+	defaultMaxPageLimit = 100
+	cursorExpirySize    = 8
+	cursorMACSize       = 16
+	maxInt              = int(^uint(0) >> 1)
+)
+
+// PaginationConfiguration - configures the pagination parameters and the cursors
+type PaginationConfiguration struct {
+
+	// DefaultLimit - the limit used when the parameter is not sent, 20 by default
+	DefaultLimit int
+
+	// MaxLimit - greater limits are reduced to this value, 100 by default
+	MaxLimit int
+
+	// CursorSecret - the key signing the cursors, it must be shared by all instances (a random one is used if empty)
+	CursorSecret string
+
+	// CursorTTL - the cursor lifetime, zero means no expiration
+	CursorTTL funks.Duration
+}
+
+// Paginator - parses the pagination parameters and renders paged results
+type Paginator struct {
+	defaultLimit int
+	maxLimit     int
+	secret       []byte
+	ttl          time.Duration
+}
+
+// Page - the requested page, Cursor is the decoded state of the previous result when using cursors
+type Page struct {
+	Number int
+	Limit  int
+	Offset int
+	Cursor []byte
+}
+
+// PageResult - a page of results, set Total (nil if unknown) or HasMore for offset paging and Next for cursor paging
+type PageResult struct {
+	Items   interface{}
+	Total   *int64
+	HasMore bool
+	Next    []byte
+}
+
+// Paged - the standard envelope of paged results
+type Paged struct {
+	Items      interface{} `json:"items"`
+	Page       int         `json:"page,omitempty"`
+	Limit      int         `json:"limit"`
+	Total      *int64      `json:"total,omitempty"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// NewPaginator - creates a new paginator
+func NewPaginator(configuration *PaginationConfiguration) (*Paginator, error) {
+
+	if configuration == nil {
+		configuration = &PaginationConfiguration{}
+	}
+
+	p := &Paginator{
+		defaultLimit: configuration.DefaultLimit,
+		maxLimit:     configuration.MaxLimit,
+		secret:       []byte(configuration.CursorSecret),
+		ttl:          configuration.CursorTTL.Duration,
+	}
+
+	if p.maxLimit <= 0 {
+		p.maxLimit = defaultMaxPageLimit
+	}
+
+	if p.defaultLimit <= 0 {
+		p.defaultLimit = defaultPageLimit
+	}
+
+	if p.defaultLimit > p.maxLimit {
+		return nil, errors.New("the default limit is greater than the max limit")
+	}
+
+	if len(p.secret) == 0 {
+		p.secret = make([]byte, sha256.Size)
+		if _, err := rand.Read(p.secret); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Parse - parses the page, limit and cursor parameters
+func (p *Paginator) Parse(r *http.Request) (*Page, gobol.Error) {
+
+	query := r.URL.Query()
+
+	page := &Page{
+		Number: 1,
+		Limit:  p.defaultLimit,
+	}
+
+	var failures []FieldError
+
+	if value := query.Get(paramLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			failures = append(failures, FieldError{Field: paramLimit, In: tagBindQuery, Message: "must be a positive integer"})
+		} else if limit > p.maxLimit {
+			page.Limit = p.maxLimit
+		} else {
+			page.Limit = limit
+		}
+	}
+
+	if value := query.Get(paramCursor); value != "" {
+		state, err := p.DecodeCursor(value)
+		if err != nil {
+			failures = append(failures, FieldError{Field: paramCursor, In: tagBindQuery, Message: err.Error()})
+		}
+		page.Cursor = state
+	} else if value := query.Get(paramPage); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			failures = append(failures, FieldError{Field: paramPage, In: tagBindQuery, Message: "must be a positive integer"})
+		} else {
+			page.Number = number
+		}
+	}
+
+	// the offset and the next page offset must not overflow
+	if page.Number > maxInt/page.Limit {
+		failures = append(failures, FieldError{Field: paramPage, In: tagBindQuery, Message: "too large"})
+	}
+
+	if len(failures) > 0 {
+		return nil, newFieldErrors("Parse", failures)
+	}
+
+	page.Offset = (page.Number - 1) * page.Limit
+
+	return page, nil
+}
+
+// EncodeCursor - signs and encodes the state of a result (a Cassandra page state or a Solr cursor mark)
+func (p *Paginator) EncodeCursor(state []byte) string {
+
+	payload := make([]byte, cursorExpirySize, cursorExpirySize+len(state)+cursorMACSize)
+
+	if p.ttl > 0 {
+		binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(p.ttl).UnixNano()))
+	}
+
+	payload = append(payload, state...)
+	payload = append(payload, p.mac(payload)...)
+
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor - checks the signature and the expiration of the cursor returning its state
+func (p *Paginator) DecodeCursor(cursor string) ([]byte, error) {
+
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(payload) < cursorExpirySize+cursorMACSize {
+		return nil, errors.New("is invalid")
+	}
+
+	signed := payload[:len(payload)-cursorMACSize]
+
+	if !hmac.Equal(p.mac(signed), payload[len(signed):]) {
+		return nil, errors.New("is invalid")
+	}
+
+	if expiry := binary.BigEndian.Uint64(signed); expiry > 0 && time.Now().UnixNano() > int64(expiry) {
+		return nil, errors.New("is expired")
+	}
+
+	return signed[cursorExpirySize:], nil
+}
+
+// mac - returns the truncated signature of the payload
+func (p *Paginator) mac(payload []byte) []byte {
+
+	h := hmac.New(sha256.New, p.secret)
+	h.Write(payload)
+
+	return h.Sum(nil)[:cursorMACSize]
+}
+
+// Links - returns the RFC 8288 links of the result, relative to the request URL
+func (p *Paginator) Links(r *http.Request, page *Page, result *PageResult) map[string]string {
+
+	links := map[string]string{}
+
+	link := func(params map[string]string) string {
+		query := r.URL.Query()
+		query.Del(paramPage)
+		query.Del(paramCursor)
+		query.Set(paramLimit, strconv.Itoa(page.Limit))
+		for k, v := range params {
+			query.Set(k, v)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		return u.String()
+	}
+
+	if result.Next != nil || page.Cursor != nil {
+		if result.Next != nil {
+			links["next"] = link(map[string]string{paramCursor: p.EncodeCursor(result.Next)})
+		}
+		links["first"] = link(nil)
+		return links
+	}
+
+	links["first"] = link(map[string]string{paramPage: "1"})
+
+	if page.Number > 1 {
+		links["prev"] = link(map[string]string{paramPage: strconv.Itoa(page.Number - 1)})
+	}
+
+	if result.Total != nil {
+		last := int((*result.Total + int64(page.Limit) - 1) / int64(page.Limit))
+		if last < 1 {
+			last = 1
+		}
+		links["last"] = link(map[string]string{paramPage: strconv.Itoa(last)})
+		if page.Number < last {
+			links["next"] = link(map[string]string{paramPage: strconv.Itoa(page.Number + 1)})
+		}
+	} else if result.HasMore {
+		links["next"] = link(map[string]string{paramPage: strconv.Itoa(page.Number + 1)})
+	}
+
+	return links
+}
+
+// SetLinkHeader - writes the links in the Link header
+func SetLinkHeader(w http.ResponseWriter, links map[string]string) {
+
+	if len(links) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(links))
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if target, ok := links[rel]; ok {
+			values = append(values, "<"+target+`>; rel="`+rel+`"`)
+		}
+	}
+
+	w.Header().Set(headerLink, strings.Join(values, ", "))
+}
+
+// SuccessPaged - writes the Link header and the result in the standard envelope
+func (p *Paginator) SuccessPaged(w http.ResponseWriter, r *http.Request, page *Page, result *PageResult) {
+
+	SetLinkHeader(w, p.Links(r, page, result))
+
+	envelope := Paged{
+		Items: result.Items,
+		Limit: page.Limit,
+	}
+
+	if result.Next != nil {
+		envelope.NextCursor = p.EncodeCursor(result.Next)
+	}
+
+	if page.Cursor == nil && result.Next == nil {
+		envelope.Page = page.Number
+	}
+
+	if result.Total != nil {
+		total := *result.Total
+		envelope.Total = &total
+	}
+
+	SuccessJSON(w, http.StatusOK, envelope)
+}
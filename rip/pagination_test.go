@@ -0,0 +1,113 @@
+package rip
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+)
+
+func TestPaginatorParse(t *testing.T) {
+
+	p, err := NewPaginator(&PaginationConfiguration{DefaultLimit: 10, MaxLimit: 50, CursorSecret: "secret"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	page, gerr := p.Parse(httptest.NewRequest(http.MethodGet, "/items", nil))
+	if assert.Nil(t, gerr) {
+		assert.Equal(t, &Page{Number: 1, Limit: 10}, page)
+	}
+
+	page, gerr = p.Parse(httptest.NewRequest(http.MethodGet, "/items?page=3&limit=500", nil))
+	if assert.Nil(t, gerr) {
+		assert.Equal(t, &Page{Number: 3, Limit: 50, Offset: 100}, page)
+	}
+
+	cursor := p.EncodeCursor([]byte("state"))
+	page, gerr = p.Parse(httptest.NewRequest(http.MethodGet, "/items?cursor="+cursor, nil))
+	if assert.Nil(t, gerr) {
+		assert.Equal(t, []byte("state"), page.Cursor)
+	}
+
+	_, gerr = p.Parse(httptest.NewRequest(http.MethodGet, "/items?page=0&limit=x&cursor=x"+cursor, nil))
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusBadRequest, gerr.StatusCode())
+		assert.Len(t, gerr.(fieldErrorsProvider).FieldErrors(), 2)
+	}
+
+	_, gerr = p.Parse(httptest.NewRequest(http.MethodGet, "/items?limit=50&page="+strconv.Itoa(maxInt/50+1), nil))
+	if assert.NotNil(t, gerr, "the offset must not overflow") {
+		assert.Equal(t, http.StatusBadRequest, gerr.StatusCode())
+	}
+
+	_, err = NewPaginator(&PaginationConfiguration{DefaultLimit: 200})
+	assert.Error(t, err)
+}
+
+func TestPaginatorCursor(t *testing.T) {
+
+	p, _ := NewPaginator(&PaginationConfiguration{CursorSecret: "secret"})
+	other, _ := NewPaginator(&PaginationConfiguration{CursorSecret: "other"})
+
+	cursor := p.EncodeCursor([]byte{1, 2, 3})
+
+	state, err := p.DecodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, state)
+
+	_, err = other.DecodeCursor(cursor)
+	assert.Error(t, err, "cursor signed with another secret")
+
+	_, err = p.DecodeCursor("AAAA")
+	assert.Error(t, err)
+
+	expiring, _ := NewPaginator(&PaginationConfiguration{CursorSecret: "secret", CursorTTL: funks.Duration{Duration: time.Millisecond}})
+	cursor = expiring.EncodeCursor([]byte{1})
+	time.Sleep(2 * time.Millisecond)
+	_, err = expiring.DecodeCursor(cursor)
+	assert.EqualError(t, err, "is expired")
+}
+
+func TestPaginatorSuccessPaged(t *testing.T) {
+
+	p, _ := NewPaginator(nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/items?q=news&page=2&limit=10", nil)
+	page, _ := p.Parse(r)
+
+	total := int64(35)
+
+	w := httptest.NewRecorder()
+	p.SuccessPaged(w, r, page, &PageResult{Items: []int{11, 12}, Total: &total})
+
+	assert.Equal(t, `</items?limit=10&page=1&q=news>; rel="first", </items?limit=10&page=1&q=news>; rel="prev", `+
+		`</items?limit=10&page=3&q=news>; rel="next", </items?limit=10&page=4&q=news>; rel="last"`, w.Header().Get(headerLink))
+	assert.JSONEq(t, `{"items":[11,12],"page":2,"limit":10,"total":35}`, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodGet, "/items?limit=10", nil)
+	page, _ = p.Parse(r)
+
+	w = httptest.NewRecorder()
+	p.SuccessPaged(w, r, page, &PageResult{Items: []int{1}, Next: []byte("state")})
+
+	envelope := Paged{}
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope)) {
+		assert.Nil(t, envelope.Total)
+		assert.Contains(t, w.Header().Get(headerLink), "cursor="+envelope.NextCursor)
+		state, err := p.DecodeCursor(envelope.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("state"), state)
+	}
+
+	w = httptest.NewRecorder()
+	p.SuccessPaged(w, r, page, &PageResult{Items: []int{1}, HasMore: true})
+
+	assert.Equal(t, `</items?limit=10&page=1>; rel="first", </items?limit=10&page=2>; rel="next"`, w.Header().Get(headerLink), "the total is unknown when not set")
+	assert.JSONEq(t, `{"items":[1],"page":1,"limit":10}`, w.Body.String())
+}
@@ -0,0 +1,215 @@
+package rip
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+)
+
+const (
+	metricRateLimitRejected string = "http.ratelimit.rejected"
+	msgTooManyRequests      string = "too many requests"
+
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+
+	defaultRateLimitShards = 16
+	defaultBucketIdleTime  = 10 * time.Minute
+)
+
+// KeyExtractor - extracts the key used to group the requests in the same bucket
+type KeyExtractor func(r *http.Request) string
+
+// RateLimitConfiguration - configures the rate limit middleware
+type RateLimitConfiguration struct {
+
+	// Rate - the number of requests per second allowed per key
+	Rate float64
+
+	// Burst - the bucket size
+	Burst int
+
+	// APIKeyHeader - when set, the requests are keyed by this header instead of the client IP (used when the header is missing)
+	APIKeyHeader string
+
+	// TrustedProxies - IPs or CIDRs allowed to set the X-Forwarded-For header
+	TrustedProxies []string
+
+	// Shards - the number of bucket shards (16 by default)
+	Shards int
+
+	// IdleTimeout - the buckets not used for this duration are evicted (10 minutes by default)
+	IdleTimeout funks.Duration
+
+	// Extractor - a custom key extractor, it overrides the other key options
+	Extractor KeyExtractor `json:"-" yaml:"-" toml:"-"`
+}
+
+// tokenBucket - a bucket of request tokens
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// bucketShard - a lock partition of buckets
+type bucketShard struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// RateLimitHandler - limits the request rate using token buckets
+type RateLimitHandler struct {
+	next        http.Handler
+	rate        float64
+	burst       float64
+	idleTimeout time.Duration
+	extractor   KeyExtractor
+	shards      []*bucketShard
+	stats       StatisticsInterface
+}
+
+// NewRateLimitMiddleware - creates a new instance of RateLimitHandler, wrap a single route handler to configure it per route (statisticsImpl can be nil)
+func NewRateLimitMiddleware(next http.Handler, configuration *RateLimitConfiguration, statisticsImpl StatisticsInterface) (*RateLimitHandler, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	if configuration.Rate <= 0 || configuration.Burst <= 0 {
+		return nil, errors.New("rate and burst must be greater than zero")
+	}
+
+	extractor := configuration.Extractor
+	if extractor == nil {
+
+		proxies, err := newProxyTrust(configuration.TrustedProxies)
+		if err != nil {
+			return nil, err
+		}
+
+		extractor = proxies.clientIP
+
+		if configuration.APIKeyHeader != "" {
+			header := configuration.APIKeyHeader
+			extractor = func(r *http.Request) string {
+				if key := r.Header.Get(header); key != "" {
+					return "key:" + key
+				}
+				return "ip:" + proxies.clientIP(r)
+			}
+		}
+	}
+
+	numShards := configuration.Shards
+	if numShards <= 0 {
+		numShards = defaultRateLimitShards
+	}
+
+	idleTimeout := configuration.IdleTimeout.Duration
+	if idleTimeout <= 0 {
+		idleTimeout = defaultBucketIdleTime
+	}
+
+	h := &RateLimitHandler{
+		next:        next,
+		rate:        configuration.Rate,
+		burst:       float64(configuration.Burst),
+		idleTimeout: idleTimeout,
+		extractor:   extractor,
+		shards:      make([]*bucketShard, numShards),
+		stats:       statisticsImpl,
+	}
+
+	now := time.Now()
+	for i := 0; i < numShards; i++ {
+		h.shards[i] = &bucketShard{
+			buckets:   map[string]*tokenBucket{},
+			lastSweep: now,
+		}
+	}
+
+	return h, nil
+}
+
+// shard - returns the shard of the key
+func (h *RateLimitHandler) shard(key string) *bucketShard {
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+
+	return h.shards[hash.Sum32()%uint32(len(h.shards))]
+}
+
+// take - takes a token from the key bucket, returning the remaining tokens and the time to wait for the next one
+func (h *RateLimitHandler) take(key string, now time.Time) (bool, float64, time.Duration) {
+
+	s := h.shard(key)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastSweep) > h.idleTimeout {
+		for k, b := range s.buckets {
+			if now.Sub(b.lastSeen) > h.idleTimeout {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{
+			tokens:   h.burst,
+			lastSeen: now,
+		}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(h.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*h.rate)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, b.tokens, 0
+	}
+
+	return false, b.tokens, time.Duration((1 - b.tokens) / h.rate * float64(time.Second))
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *RateLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	allowed, tokens, wait := h.take(h.extractor(r), time.Now())
+
+	headers := w.Header()
+	headers.Set(headerRateLimitLimit, strconv.Itoa(int(h.burst)))
+	headers.Set(headerRateLimitRemaining, strconv.Itoa(int(tokens)))
+	headers.Set(headerRateLimitReset, strconv.Itoa(int(math.Ceil((h.burst-tokens)/h.rate))))
+
+	if allowed {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	headers.Set(headerRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	if h.stats != nil {
+		h.stats.Increment(metricRateLimitRejected, tagMethod, r.Method, tagPath, routePattern(r, nil))
+	}
+
+	FailContext(
+		r.Context(),
+		w,
+		errBasic("rip", "ServeHTTP", msgTooManyRequests, http.StatusTooManyRequests, errors.New(http.StatusText(http.StatusTooManyRequests))),
+	)
+}
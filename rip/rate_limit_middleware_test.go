@@ -0,0 +1,75 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	h, err := NewRateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &RateLimitConfiguration{
+		Rate:         1,
+		Burst:        2,
+		APIKeyHeader: "X-API-Key",
+	}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/search", nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("a").Code)
+
+	w := serve("a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(headerRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(headerRateLimitRemaining))
+
+	w = serve("a")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(headerRetryAfter))
+	assert.Equal(t, 1, stats.increments[metricRateLimitRejected])
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, strUndefined}, stats.tags[metricRateLimitRejected], "the raw path must not be a tag")
+
+	assert.Equal(t, http.StatusOK, serve("b").Code, "other keys must have their own buckets")
+}
+
+func TestRateLimitMissingAPIKey(t *testing.T) {
+
+	h, err := NewRateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &RateLimitConfiguration{
+		Rate:         1,
+		Burst:        1,
+		APIKeyHeader: "X-API-Key",
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	serve := func(remoteAddr string) int {
+		r := httptest.NewRequest(http.MethodGet, "/search", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, serve("10.0.0.2:1234"), "requests without key must be keyed by the client IP")
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1:4321"))
+}
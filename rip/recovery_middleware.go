@@ -0,0 +1,80 @@
+package rip
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+const (
+	metricPanicCount string = "http.panic.count"
+	msgInternalError string = "internal server error"
+)
+
+// RecoveryHandler - recovers from handler panics responding a 500 error through Fail
+type RecoveryHandler struct {
+	next   http.Handler
+	stats  StatisticsInterface
+	logger *logh.ContextualLogger
+}
+
+// NewRecoveryMiddleware - creates a new instance of RecoveryHandler, the panic metric is tagged by the route pattern
+// when it wraps the Router or a route handler (statisticsImpl can be nil)
+func NewRecoveryMiddleware(next http.Handler, statisticsImpl StatisticsInterface) *RecoveryHandler {
+
+	return &RecoveryHandler{
+		next:   next,
+		stats:  statisticsImpl,
+		logger: logh.CreateContextualLogger("pkg", "rip", "type", "recovery"),
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *RecoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	logResponseWriter := &LogResponseWriter{
+		ResponseWriter: w,
+	}
+
+	r, holder := withRouteHolder(r)
+
+	defer func() {
+
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		if logh.ErrorEnabled {
+			gobol.LogWithRequestID(r.Context(), h.logger.Error()).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Str("stack", string(debug.Stack())).
+				Msg(fmt.Sprintf("recovered from panic: %v", recovered))
+		}
+
+		if h.stats != nil {
+			h.stats.Increment(metricPanicCount, tagMethod, r.Method, tagPath, routePattern(r, holder))
+		}
+
+		if logResponseWriter.status != 0 {
+			return
+		}
+
+		FailContext(
+			r.Context(),
+			w,
+			errBasic("rip", "ServeHTTP", msgInternalError, http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError))),
+		)
+	}()
+
+	h.next.ServeHTTP(logResponseWriter, r)
+}
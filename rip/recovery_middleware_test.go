@@ -0,0 +1,52 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+func TestRecovery(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	router := NewRouter()
+	router.GET("/documents/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		panic("boom")
+	})
+	router.GET("/written", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+
+	h := NewRecoveryMiddleware(router, stats)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/documents/1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), msgInternalError)
+
+	stats.mutex.Lock()
+	assert.Equal(t, 1, stats.increments[metricPanicCount])
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/documents/:id"}, stats.tags[metricPanicCount], "the route pattern must be used")
+	stats.mutex.Unlock()
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/written", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code, "a written response must not be replaced")
+
+	assert.Panics(t, func() {
+		NewRecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}), nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}, "aborted handlers must keep panicking")
+}
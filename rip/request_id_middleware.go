@@ -0,0 +1,78 @@
+package rip
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/uol/gobol"
+)
+
+const (
+	headerRequestID    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// RequestIDHandler - accepts or generates a request id and propagates it through the request context
+type RequestIDHandler struct {
+	next http.Handler
+}
+
+// NewRequestIDMiddleware - creates a new instance of RequestIDHandler
+func NewRequestIDMiddleware(next http.Handler) *RequestIDHandler {
+
+	return &RequestIDHandler{
+		next: next,
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *RequestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	requestID := r.Header.Get(headerRequestID)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+		r.Header.Set(headerRequestID, requestID)
+	}
+
+	w.Header().Set(headerRequestID, requestID)
+
+	h.next.ServeHTTP(w, r.WithContext(gobol.ContextWithRequestID(r.Context(), requestID)))
+}
+
+// RequestID - returns the request id of the request
+func RequestID(r *http.Request) string {
+
+	if requestID := gobol.RequestIDFromContext(r.Context()); requestID != "" {
+		return requestID
+	}
+
+	return r.Header.Get(headerRequestID)
+}
+
+// validRequestID - checks if the received request id is safe to be logged and echoed
+func validRequestID(requestID string) bool {
+
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID - generates a new random request id
+func newRequestID() string {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,53 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+// serveRequestID - serves a request through the request id middleware and returns the id seen by the handler
+func serveRequestID(t *testing.T, received string) (string, *httptest.ResponseRecorder) {
+
+	var seen string
+	h := NewRequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = gobol.RequestIDFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if received != "" {
+		r.Header.Set(headerRequestID, received)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return seen, w
+}
+
+func TestRequestIDAccepted(t *testing.T) {
+
+	seen, w := serveRequestID(t, "abc-123")
+
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", w.Header().Get(headerRequestID))
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+
+	seen, w := serveRequestID(t, "")
+
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(headerRequestID))
+}
+
+func TestRequestIDInvalidReplaced(t *testing.T) {
+
+	seen, _ := serveRequestID(t, "bad id\n")
+
+	assert.NotEqual(t, "bad id\n", seen)
+	assert.Len(t, seen, 32)
+}
@@ -2,6 +2,7 @@ package rip
 
 import (
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -22,10 +23,11 @@ var (
 
 type customError struct {
 	error
-	msg      string
-	pkg      string
-	function string
-	httpCode int
+	msg       string
+	pkg       string
+	function  string
+	httpCode  int
+	errorCode string
 }
 
 func (e customError) Package() string {
@@ -45,7 +47,7 @@ func (e customError) StatusCode() int {
 }
 
 func (e customError) ErrorCode() string {
-	return ""
+	return e.errorCode
 }
 
 type Validator interface {
@@ -53,11 +55,12 @@ type Validator interface {
 }
 
 type errorJSON struct {
-	Error   interface{} `json:"error,omitempty"`
-	Message interface{} `json:"message,omitempty"`
+	Error   interface{}  `json:"error,omitempty"`
+	Message interface{}  `json:"message,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
 }
 
-func logError(gerr gobol.Error) *zerolog.Event {
+func logError(ctx context.Context, gerr gobol.Error) *zerolog.Event {
 	if logger == nil {
 		return nil
 	}
@@ -74,6 +77,7 @@ func logError(gerr gobol.Error) *zerolog.Event {
 	}
 
 	if ev != nil {
+		ev = gobol.LogWithRequestID(ctx, ev)
 		ev.Str("pkg", gerr.Package()).Str("func", gerr.Function()).Err(gerr).Msg(gerr.Message())
 		return ev
 	}
@@ -89,6 +93,22 @@ func errBasic(pkg, function, message string, code int, e error) gobol.Error {
 			pkg,
 			function,
 			code,
+			"",
+		}
+	}
+	return nil
+}
+
+// errWithCode - same as errBasic with an error code mapped by the error messages file
+func errWithCode(pkg, function, message, errorCode string, code int, e error) gobol.Error {
+	if e != nil {
+		return customError{
+			e,
+			message,
+			pkg,
+			function,
+			code,
+			errorCode,
 		}
 	}
 	return nil
@@ -160,6 +180,12 @@ func Success(w http.ResponseWriter, statusCode int, payload []byte) {
 
 func Fail(w http.ResponseWriter, gerr gobol.Error) {
 
+	FailContext(context.Background(), w, gerr)
+}
+
+// FailContext - same as Fail, logging the error with the request id found in the context
+func FailContext(ctx context.Context, w http.ResponseWriter, gerr gobol.Error) {
+
 	var errorMessage string
 	if gerr.ErrorCode() == "" {
 		errorMessage = gerr.Message()
@@ -170,7 +196,7 @@ func Fail(w http.ResponseWriter, gerr gobol.Error) {
 	defer func() {
 		if r := recover(); r != nil {
 
-			if ev := logError(gerr); ev == nil {
+			if ev := logError(ctx, gerr); ev == nil {
 				log.Println(gerr.Message())
 			}
 
@@ -195,7 +221,7 @@ func Fail(w http.ResponseWriter, gerr gobol.Error) {
 		}
 	}()
 
-	if ev := logError(gerr); ev == nil {
+	if ev := logError(ctx, gerr); ev == nil {
 		log.Println(gerr.Message())
 	}
 
@@ -209,6 +235,10 @@ func Fail(w http.ResponseWriter, gerr gobol.Error) {
 		Message: errorMessage,
 	}
 
+	if provider, ok := gerr.(fieldErrorsProvider); ok {
+		ej.Fields = provider.FieldErrors()
+	}
+
 	w.WriteHeader(gerr.StatusCode())
 
 	e := jsonMarshaller.NewEncoder(w)
@@ -0,0 +1,344 @@
+package rip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/uol/gobol"
+)
+
+const (
+	routeKey       dummyKeyType = 3
+	routeHolderKey dummyKeyType = 4
+)
+
+// Middleware - wraps a http.Handler
+type Middleware func(next http.Handler) http.Handler
+
+// HandlerFunc - a route handler, the returned error is rendered by Fail
+type HandlerFunc func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error
+
+// Route - a registered route
+type Route struct {
+
+	// Name - the route name used to build URLs (optional)
+	Name string
+
+	// Method - the HTTP method
+	Method string
+
+	// Path - the full path pattern
+	Path string
+
+	// Authorization - the rule checked by the Authorizer middleware
+	Authorization *AuthorizationRule
+
+	// CacheTTL - the lifetime of the responses cached by the cache middleware
+	CacheTTL time.Duration
+
+	// SlowThreshold - the duration from which the requests are reported by the slow request middleware
+	SlowThreshold time.Duration
+
+	// Doc - the documentation used to generate the OpenAPI document
+	Doc *RouteDoc
+
+	middlewares []Middleware
+}
+
+// RouteOption - configures a route on registration
+type RouteOption func(route *Route)
+
+// Name - names the route
+func Name(name string) RouteOption {
+	return func(route *Route) {
+		route.Name = name
+	}
+}
+
+// With - adds middlewares to the route only
+func With(middlewares ...Middleware) RouteOption {
+	return func(route *Route) {
+		route.middlewares = append(route.middlewares, middlewares...)
+	}
+}
+
+// Authorization - sets the authorization rule of the route
+func Authorization(rule *AuthorizationRule) RouteOption {
+	return func(route *Route) {
+		route.Authorization = rule
+	}
+}
+
+// RouteFromContext - returns the route matched or nil
+func RouteFromContext(ctx context.Context) *Route {
+
+	route, _ := ctx.Value(routeKey).(*Route)
+
+	return route
+}
+
+// routeHolder - lets the middlewares wrapping the router know the route matched and its parameters
+type routeHolder struct {
+	mutex  sync.Mutex
+	route  *Route
+	params httprouter.Params
+}
+
+// withRouteHolder - installs a route holder in the request context if there is none
+func withRouteHolder(r *http.Request) (*http.Request, *routeHolder) {
+
+	if holder, ok := r.Context().Value(routeHolderKey).(*routeHolder); ok {
+		return r, holder
+	}
+
+	holder := &routeHolder{}
+
+	return r.WithContext(context.WithValue(r.Context(), routeHolderKey, holder)), holder
+}
+
+// routePattern - returns the pattern of the route matched for the metric tags, the raw path would create a series per URL
+func routePattern(r *http.Request, holder *routeHolder) string {
+
+	if holder != nil {
+		// the router may still be running, as when the timeout middleware gives up on it
+		holder.mutex.Lock()
+		route := holder.route
+		holder.mutex.Unlock()
+
+		if route != nil {
+			return route.Path
+		}
+	}
+
+	if route := RouteFromContext(r.Context()); route != nil {
+		return route.Path
+	}
+
+	return strUndefined
+}
+
+// routeRegistry - the routes shared by a router and its groups
+type routeRegistry struct {
+	mutex  sync.RWMutex
+	routes []*Route
+	named  map[string]*Route
+}
+
+// Router - wraps the custom router adding route groups, middlewares and named routes
+type Router struct {
+	router      *httprouter.Router
+	prefix      string
+	middlewares []Middleware
+	registry    *routeRegistry
+}
+
+// NewRouter - creates a new router based on the custom router
+func NewRouter() *Router {
+
+	return &Router{
+		router: NewCustomRouter(),
+		registry: &routeRegistry{
+			named: map[string]*Route{},
+		},
+	}
+}
+
+// NewRouterMapError - creates a new router mapping error codes to messages according to errorMessagesFile
+func NewRouterMapError(errorMessagesFile string) *Router {
+
+	r := NewRouter()
+	r.router = NewCustomRouterMapError(errorMessagesFile)
+
+	return r
+}
+
+// HTTPRouter - returns the underlying router
+func (r *Router) HTTPRouter() *httprouter.Router {
+	return r.router
+}
+
+// Use - adds middlewares to the routes registered after this call
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Group - creates a group of routes sharing the path prefix and the middlewares
+func (r *Router) Group(prefix string, middlewares ...Middleware) *Router {
+
+	groupMiddlewares := make([]Middleware, 0, len(r.middlewares)+len(middlewares))
+	groupMiddlewares = append(groupMiddlewares, r.middlewares...)
+	groupMiddlewares = append(groupMiddlewares, middlewares...)
+
+	return &Router{
+		router:      r.router,
+		prefix:      joinPaths(r.prefix, prefix),
+		middlewares: groupMiddlewares,
+		registry:    r.registry,
+	}
+}
+
+// joinPaths - joins the prefix and the path
+func joinPaths(prefix, path string) string {
+
+	if prefix == "" {
+		return path
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Handler - registers a plain http.Handler, the parameters are available with httprouter.ParamsFromContext
+func (r *Router) Handler(method, path string, handler http.Handler, options ...RouteOption) *Route {
+
+	route := &Route{
+		Method: method,
+		Path:   joinPaths(r.prefix, path),
+	}
+
+	for _, option := range options {
+		option(route)
+	}
+
+	chain := handler
+	for i := len(route.middlewares) - 1; i >= 0; i-- {
+		chain = route.middlewares[i](chain)
+	}
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		chain = r.middlewares[i](chain)
+	}
+
+	r.registry.mutex.Lock()
+	if route.Name != "" {
+		if _, exists := r.registry.named[route.Name]; exists {
+			r.registry.mutex.Unlock()
+			panic(fmt.Sprintf("route name already registered: %s", route.Name))
+		}
+		r.registry.named[route.Name] = route
+	}
+	r.registry.routes = append(r.registry.routes, route)
+	r.registry.mutex.Unlock()
+
+	r.router.Handle(method, route.Path, func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+
+		if holder, ok := req.Context().Value(routeHolderKey).(*routeHolder); ok {
+			holder.mutex.Lock()
+			holder.route = route
+			holder.params = ps
+			holder.mutex.Unlock()
+		}
+
+		ctx := context.WithValue(req.Context(), httprouter.ParamsKey, ps)
+		ctx = context.WithValue(ctx, routeKey, route)
+
+		chain.ServeHTTP(w, req.WithContext(ctx))
+	})
+
+	return route
+}
+
+// Handle - registers a route handler
+func (r *Router) Handle(method, path string, handler HandlerFunc, options ...RouteOption) *Route {
+
+	return r.Handler(method, path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if gerr := handler(w, req, httprouter.ParamsFromContext(req.Context())); gerr != nil {
+			FailContext(req.Context(), w, gerr)
+		}
+	}), options...)
+}
+
+// GET - registers a GET route
+func (r *Router) GET(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodGet, path, handler, options...)
+}
+
+// HEAD - registers a HEAD route
+func (r *Router) HEAD(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodHead, path, handler, options...)
+}
+
+// POST - registers a POST route
+func (r *Router) POST(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodPost, path, handler, options...)
+}
+
+// PUT - registers a PUT route
+func (r *Router) PUT(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodPut, path, handler, options...)
+}
+
+// PATCH - registers a PATCH route
+func (r *Router) PATCH(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodPatch, path, handler, options...)
+}
+
+// DELETE - registers a DELETE route
+func (r *Router) DELETE(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodDelete, path, handler, options...)
+}
+
+// OPTIONS - registers an OPTIONS route
+func (r *Router) OPTIONS(path string, handler HandlerFunc, options ...RouteOption) *Route {
+	return r.Handle(http.MethodOptions, path, handler, options...)
+}
+
+// Routes - returns all registered routes
+func (r *Router) Routes() []*Route {
+
+	r.registry.mutex.RLock()
+	defer r.registry.mutex.RUnlock()
+
+	routes := make([]*Route, len(r.registry.routes))
+	copy(routes, r.registry.routes)
+
+	return routes
+}
+
+// URL - builds the path of a named route replacing the parameters given as name and value pairs
+func (r *Router) URL(name string, pairs ...string) (string, error) {
+
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("the number of parameters must be even")
+	}
+
+	r.registry.mutex.RLock()
+	route, ok := r.registry.named[name]
+	r.registry.mutex.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("route not found: %s", name)
+	}
+
+	values := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		values[pairs[i]] = pairs[i+1]
+	}
+
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			value, ok := values[segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("missing parameter %s for route %s", segment[1:], name)
+			}
+			if segment[0] == '*' {
+				value = strings.TrimPrefix(value, "/")
+			} else {
+				value = url.PathEscape(value)
+			}
+			segments[i] = value
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.router.ServeHTTP(w, req)
+}
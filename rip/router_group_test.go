@@ -0,0 +1,99 @@
+package rip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+// tagMiddleware - appends the tag to the X-Chain response header
+func tagMiddleware(tag string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", tag)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// serveRouter - serves a request using the router
+func serveRouter(router http.Handler, method, path string) *httptest.ResponseRecorder {
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+	return w
+}
+
+func TestRouterGroups(t *testing.T) {
+
+	router := NewRouter()
+	router.Use(tagMiddleware("root"))
+
+	api := router.Group("/api/v1", tagMiddleware("api"))
+
+	var route *Route
+	api.GET("/collections/:collection", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		route = RouteFromContext(r.Context())
+		Success(w, http.StatusOK, []byte(ps.ByName("collection")))
+		return nil
+	}, Name("collection"), With(tagMiddleware("route")))
+
+	api.DELETE("/collections/:collection", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		return errBasic("rip", "delete", "not allowed", http.StatusConflict, errors.New("conflict"))
+	})
+
+	w := serveRouter(router, http.MethodGet, "/api/v1/collections/news")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "news", w.Body.String())
+	assert.Equal(t, []string{"root", "api", "route"}, w.Header()["X-Chain"])
+	if assert.NotNil(t, route) {
+		assert.Equal(t, "/api/v1/collections/:collection", route.Path)
+	}
+
+	w = serveRouter(router, http.MethodDelete, "/api/v1/collections/news")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "not allowed"))
+	assert.Equal(t, []string{"root", "api"}, w.Header()["X-Chain"])
+
+	url, err := router.URL("collection", "collection", "a b")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/collections/a%20b", url)
+
+	_, err = router.URL("collection")
+	assert.Error(t, err)
+
+	assert.Len(t, router.Routes(), 2)
+}
+
+func TestRouterAuthorization(t *testing.T) {
+
+	router := NewRouter()
+	router.Use(NewAuthorizer(nil, &AuthorizationConfiguration{DenyByDefault: true}).Middleware())
+
+	ok := func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	router.GET("/public", ok, Authorization(&AuthorizationRule{Public: true}))
+	router.GET("/undeclared", ok)
+	router.GET("/admin", ok, Authorization(&AuthorizationRule{Roles: []string{"admin"}}))
+
+	withPrincipal := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), &Principal{ID: "user", Roles: []string{"reader"}})))
+		})
+	}
+
+	assert.Equal(t, http.StatusNoContent, serveRouter(router, http.MethodGet, "/public").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveRouter(router, http.MethodGet, "/admin").Code)
+	assert.Equal(t, http.StatusForbidden, serveRouter(withPrincipal(router), http.MethodGet, "/admin").Code)
+	assert.Equal(t, http.StatusForbidden, serveRouter(withPrincipal(router), http.MethodGet, "/undeclared").Code)
+}
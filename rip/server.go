@@ -0,0 +1,326 @@
+package rip
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/logh"
+)
+
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	defaultTLSReloadCheck  = time.Minute
+)
+
+// ServerConfiguration - configures the managed HTTP server
+type ServerConfiguration struct {
+
+	// Address - the listening address, like ":8080"
+	Address string
+
+	// ReadTimeout - the maximum duration reading the whole request
+	ReadTimeout funks.Duration
+
+	// ReadHeaderTimeout - the maximum duration reading the request headers
+	ReadHeaderTimeout funks.Duration
+
+	// WriteTimeout - the maximum duration writing the response
+	WriteTimeout funks.Duration
+
+	// IdleTimeout - the maximum duration of idle keep-alive connections
+	IdleTimeout funks.Duration
+
+	// MaxHeaderBytes - the maximum size of the request headers
+	MaxHeaderBytes int
+
+	// TLSCertFile - the certificate file, enables TLS with TLSKeyFile
+	TLSCertFile string
+
+	// TLSKeyFile - the private key file
+	TLSKeyFile string
+
+	// TLSReloadInterval - the interval between checks of the certificate files modification (1 minute by default)
+	TLSReloadInterval funks.Duration
+
+	// Compression - enables the gzip middleware
+	Compression bool
+
+	// CompressionLevel - the gzip compression level (default compression when zero)
+	CompressionLevel int
+
+	// DrainDelay - the duration the server keeps serving as not ready before shutting down
+	DrainDelay funks.Duration
+
+	// ShutdownTimeout - the maximum duration waiting for the active requests on shutdown (30 seconds by default)
+	ShutdownTimeout funks.Duration
+}
+
+// Server - a HTTP server with graceful shutdown, TLS reloading and readiness control
+type Server struct {
+	configuration *ServerConfiguration
+	server        *http.Server
+	addr          atomic.Value
+	ready         int32
+	certMutex     sync.Mutex
+	cert          *tls.Certificate
+	certModTime   time.Time
+	certLastCheck time.Time
+	shutdown      chan struct{}
+	shutdownOnce  sync.Once
+	logger        *logh.ContextualLogger
+}
+
+// NewServer - creates a new server wrapping the handler with LogHandler (when statisticsImpl is not nil) and the compression
+func NewServer(configuration *ServerConfiguration, handler http.Handler, statisticsImpl StatisticsInterface) (*Server, error) {
+
+	if configuration == nil {
+		return nil, errors.New("null configuration")
+	}
+
+	if (configuration.TLSCertFile == "") != (configuration.TLSKeyFile == "") {
+		return nil, errors.New("both the certificate and the key files are required to enable TLS")
+	}
+
+	if configuration.Compression {
+		level := configuration.CompressionLevel
+		if level == NoCompression {
+			level = DefaultCompression
+		}
+		handler = NewGzipMiddleware(level, handler)
+	}
+
+	if statisticsImpl != nil {
+		_, port, err := net.SplitHostPort(configuration.Address)
+		if err != nil {
+			return nil, err
+		}
+		portNumber, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, err
+		}
+		handler = NewLogMiddleware(handler, portNumber, statisticsImpl)
+	}
+
+	s := &Server{
+		configuration: configuration,
+		shutdown:      make(chan struct{}),
+		logger:        logh.CreateContextualLogger("pkg", "rip", "type", "server"),
+	}
+
+	s.server = &http.Server{
+		Addr:              configuration.Address,
+		Handler:           handler,
+		ReadTimeout:       configuration.ReadTimeout.Duration,
+		ReadHeaderTimeout: configuration.ReadHeaderTimeout.Duration,
+		WriteTimeout:      configuration.WriteTimeout.Duration,
+		IdleTimeout:       configuration.IdleTimeout.Duration,
+		MaxHeaderBytes:    configuration.MaxHeaderBytes,
+	}
+
+	if configuration.TLSCertFile != "" {
+		if err := s.loadCertificate(); err != nil {
+			return nil, err
+		}
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.getCertificate,
+		}
+	}
+
+	return s, nil
+}
+
+// loadCertificate - loads the certificate files
+func (s *Server) loadCertificate() error {
+
+	info, err := os.Stat(s.configuration.TLSCertFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.configuration.TLSCertFile, s.configuration.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.certMutex.Lock()
+	defer s.certMutex.Unlock()
+
+	s.cert = &cert
+	s.certModTime = info.ModTime()
+	s.certLastCheck = time.Now()
+
+	return nil
+}
+
+// getCertificate - returns the certificate, reloading it when the file was modified
+func (s *Server) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	interval := s.configuration.TLSReloadInterval.Duration
+	if interval <= 0 {
+		interval = defaultTLSReloadCheck
+	}
+
+	s.certMutex.Lock()
+	cert := s.cert
+	check := time.Since(s.certLastCheck) > interval
+	if check {
+		s.certLastCheck = time.Now()
+	}
+	modTime := s.certModTime
+	s.certMutex.Unlock()
+
+	if !check {
+		return cert, nil
+	}
+
+	info, err := os.Stat(s.configuration.TLSCertFile)
+	if err != nil || !info.ModTime().After(modTime) {
+		return cert, nil
+	}
+
+	if err := s.loadCertificate(); err != nil {
+		if logh.ErrorEnabled {
+			s.logger.Error().Err(err).Msg("error reloading the certificate, keeping the current one")
+		}
+		return cert, nil
+	}
+
+	if logh.InfoEnabled {
+		s.logger.Info().Msg("certificate reloaded")
+	}
+
+	s.certMutex.Lock()
+	defer s.certMutex.Unlock()
+
+	return s.cert, nil
+}
+
+// Addr - returns the address the server is listening on, nil if not running yet
+func (s *Server) Addr() net.Addr {
+
+	addr, _ := s.addr.Load().(net.Addr)
+
+	return addr
+}
+
+// Ready - checks if the server is ready to receive requests
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// SetReady - changes the server readiness
+func (s *Server) SetReady(ready bool) {
+
+	var value int32
+	if ready {
+		value = 1
+	}
+
+	atomic.StoreInt32(&s.ready, value)
+}
+
+// ReadinessHandler - answers 200 when ready and 503 otherwise
+func (s *Server) ReadinessHandler() http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Ready() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Run - listens and serves until SIGTERM or SIGINT is received, then drains and shuts down gracefully
+func (s *Server) Run() error {
+
+	listener, err := net.Listen("tcp", s.configuration.Address)
+	if err != nil {
+		return err
+	}
+
+	s.addr.Store(listener.Addr())
+
+	// registered before serving so a signal received while starting is not lost
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	// Serve changes the TLS configuration, it must not be read after starting
+	tlsEnabled := s.server.TLSConfig != nil
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		if tlsEnabled {
+			serveErr <- s.server.ServeTLS(listener, "", "")
+		} else {
+			serveErr <- s.server.Serve(listener)
+		}
+	}()
+
+	s.SetReady(true)
+
+	if logh.InfoEnabled {
+		s.logger.Info().Str("address", listener.Addr().String()).Bool("tls", tlsEnabled).Msg("server started")
+	}
+
+	select {
+	case err := <-serveErr:
+		s.SetReady(false)
+		return err
+	case sig := <-signals:
+		if logh.InfoEnabled {
+			s.logger.Info().Str("signal", sig.String()).Msg("shutting down")
+		}
+	case <-s.shutdown:
+	}
+
+	return s.drain()
+}
+
+// drain - marks the server as not ready, waits the drain delay and shuts down, the shutdown timeout starts after the delay
+func (s *Server) drain() error {
+
+	s.SetReady(false)
+
+	if delay := s.configuration.DrainDelay.Duration; delay > 0 {
+		time.Sleep(delay)
+	}
+
+	timeout := s.configuration.ShutdownTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+
+	if logh.InfoEnabled {
+		s.logger.Info().Msg("server stopped")
+	}
+
+	return err
+}
+
+// Stop - asks a running server to shut down gracefully as if a SIGTERM was received
+func (s *Server) Stop() {
+
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
+}
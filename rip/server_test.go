@@ -0,0 +1,100 @@
+package rip
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+)
+
+// startServer - runs the server in background waiting until it is ready
+func startServer(t *testing.T, s *Server) <-chan error {
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.Run()
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.Ready() {
+		if time.Now().After(deadline) {
+			t.Fatal("server not ready")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return result
+}
+
+func TestServerStop(t *testing.T) {
+
+	s, err := NewServer(&ServerConfiguration{Address: "127.0.0.1:0"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result := startServer(t, s)
+
+	res, err := http.Get("http://" + s.Addr().String())
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	s.Stop()
+	s.Stop()
+
+	assert.NoError(t, <-result)
+	assert.False(t, s.Ready())
+}
+
+func TestServerDrain(t *testing.T) {
+
+	started := make(chan struct{}, 1)
+
+	s, err := NewServer(&ServerConfiguration{
+		Address:         "127.0.0.1:0",
+		DrainDelay:      funks.Duration{Duration: 200 * time.Millisecond},
+		ShutdownTimeout: funks.Duration{Duration: 150 * time.Millisecond},
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			time.Sleep(250 * time.Millisecond)
+		}
+	}), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result := startServer(t, s)
+	url := "http://" + s.Addr().String()
+
+	slow := make(chan int, 1)
+	go func() {
+		res, err := http.Get(url + "/slow")
+		if err != nil {
+			slow <- 0
+			return
+		}
+		res.Body.Close()
+		slow <- res.StatusCode
+	}()
+
+	<-started
+	s.Stop()
+
+	for s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+
+	res, err := http.Get(url)
+	if assert.NoError(t, err, "the server must keep serving during the drain delay") {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	assert.NoError(t, <-result, "the shutdown timeout must start after the drain delay")
+	assert.Equal(t, http.StatusOK, <-slow)
+}
@@ -0,0 +1,287 @@
+package rip
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+)
+
+const (
+	headerSignature          = "X-Signature"
+	headerSignatureKeyID     = "X-Signature-Key-Id"
+	headerSignatureTimestamp = "X-Signature-Timestamp"
+	headerSignatureNonce     = "X-Signature-Nonce"
+
+	defaultSignatureClockSkew   = 5 * time.Minute
+	defaultSignatureMaxBodySize = 10 << 20
+	maxNonceLength              = 128
+
+	msgPayloadTooLarge string = "payload too large"
+)
+
+// signedTarget - returns the escaped path followed by the query sorted by key
+func signedTarget(u *url.URL) string {
+
+	if u.RawQuery == "" {
+		return u.EscapedPath()
+	}
+
+	return u.EscapedPath() + "?" + u.Query().Encode()
+}
+
+// signatureBase - builds the signed string: method, target, timestamp, nonce and body hash
+func signatureBase(method, target, timestamp, nonce string, body []byte) []byte {
+
+	bodyHash := sha256.Sum256(body)
+
+	return []byte(strings.Join([]string{
+		strings.ToUpper(method),
+		target,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n"))
+}
+
+// computeSignature - returns the HMAC-SHA256 of the signed string
+func computeSignature(secret, base []byte) []byte {
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(base)
+
+	return mac.Sum(nil)
+}
+
+// Signer - signs outgoing requests to endpoints protected by the signature middleware
+type Signer struct {
+	keyID  string
+	secret []byte
+}
+
+// NewSigner - creates a new signer
+func NewSigner(keyID, secret string) *Signer {
+
+	return &Signer{
+		keyID:  keyID,
+		secret: []byte(secret),
+	}
+}
+
+// Sign - adds the signature headers to the request, the body is read and restored
+func (s *Signer) Sign(r *http.Request) error {
+
+	var body []byte
+
+	if r.Body != nil && r.Body != http.NoBody {
+
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceHex := hex.EncodeToString(nonce)
+
+	r.Header.Set(headerSignatureKeyID, s.keyID)
+	r.Header.Set(headerSignatureTimestamp, timestamp)
+	r.Header.Set(headerSignatureNonce, nonceHex)
+	r.Header.Set(headerSignature, hex.EncodeToString(computeSignature(s.secret, signatureBase(r.Method, signedTarget(r.URL), timestamp, nonceHex, body))))
+
+	return nil
+}
+
+// nonceCache - remembers the nonces seen inside the clock skew window
+type nonceCache struct {
+	mutex     sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// add - adds the nonce, returning false if it was already seen
+func (c *nonceCache) add(nonce string, now time.Time, ttl time.Duration) bool {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if now.Sub(c.lastSweep) > ttl {
+		for n, expires := range c.nonces {
+			if now.After(expires) {
+				delete(c.nonces, n)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expires, ok := c.nonces[nonce]; ok && now.Before(expires) {
+		return false
+	}
+
+	c.nonces[nonce] = now.Add(ttl)
+
+	return true
+}
+
+// SignatureConfiguration - configures the signature verification middleware
+type SignatureConfiguration struct {
+
+	// Keys - the shared secrets by key id
+	Keys map[string]string
+
+	// ClockSkew - the maximum difference between the request timestamp and the server clock (5 minutes by default)
+	ClockSkew funks.Duration
+
+	// MaxBodySize - the maximum body size in bytes (10MB by default)
+	MaxBodySize int64
+}
+
+// SignatureHandler - verifies the HMAC-SHA256 signature of the requests
+type SignatureHandler struct {
+	next        http.Handler
+	keys        map[string][]byte
+	clockSkew   time.Duration
+	maxBodySize int64
+	nonces      *nonceCache
+}
+
+// NewSignatureMiddleware - creates a new instance of SignatureHandler
+func NewSignatureMiddleware(next http.Handler, configuration *SignatureConfiguration) (*SignatureHandler, error) {
+
+	if configuration == nil || len(configuration.Keys) == 0 {
+		return nil, errors.New("no signature keys configured")
+	}
+
+	h := &SignatureHandler{
+		next:        next,
+		keys:        make(map[string][]byte, len(configuration.Keys)),
+		clockSkew:   configuration.ClockSkew.Duration,
+		maxBodySize: configuration.MaxBodySize,
+		nonces: &nonceCache{
+			nonces:    map[string]time.Time{},
+			lastSweep: time.Now(),
+		},
+	}
+
+	for keyID, secret := range configuration.Keys {
+		if secret == "" {
+			return nil, errors.New("empty secret for key " + keyID)
+		}
+		h.keys[keyID] = []byte(secret)
+	}
+
+	if h.clockSkew <= 0 {
+		h.clockSkew = defaultSignatureClockSkew
+	}
+
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = defaultSignatureMaxBodySize
+	}
+
+	return h, nil
+}
+
+// verify - verifies the signature headers and returns the key id
+func (h *SignatureHandler) verify(r *http.Request, body []byte) (string, error) {
+
+	keyID := r.Header.Get(headerSignatureKeyID)
+	timestamp := r.Header.Get(headerSignatureTimestamp)
+	nonce := r.Header.Get(headerSignatureNonce)
+	signature := r.Header.Get(headerSignature)
+
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", errors.New("missing signature headers")
+	}
+
+	secret, ok := h.keys[keyID]
+	if !ok {
+		return "", errors.New("unknown key id")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("invalid timestamp")
+	}
+
+	now := time.Now()
+	sent := time.Unix(seconds, 0)
+	if sent.Before(now.Add(-h.clockSkew)) || sent.After(now.Add(h.clockSkew)) {
+		return "", errors.New("timestamp outside the allowed window")
+	}
+
+	received, err := hex.DecodeString(signature)
+	if err != nil {
+		return "", errors.New("invalid signature encoding")
+	}
+
+	if !hmac.Equal(received, computeSignature(secret, signatureBase(r.Method, signedTarget(r.URL), timestamp, nonce, body))) {
+		return "", errors.New("invalid signature")
+	}
+
+	if len(nonce) > maxNonceLength || !h.nonces.add(keyID+":"+nonce, now, 2*h.clockSkew) {
+		return "", errors.New("replayed request")
+	}
+
+	return keyID, nil
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *SignatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var body []byte
+
+	if r.Body != nil {
+
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, h.maxBodySize+1))
+		r.Body.Close()
+		if err != nil {
+			FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", "error reading the body", http.StatusBadRequest, err))
+			return
+		}
+
+		if int64(len(body)) > h.maxBodySize {
+			FailContext(r.Context(), w, errBasic("rip", "ServeHTTP", msgPayloadTooLarge, http.StatusRequestEntityTooLarge, errors.New(http.StatusText(http.StatusRequestEntityTooLarge))))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	keyID, err := h.verify(r, body)
+	if err != nil {
+		FailContext(r.Context(), w, errUnauthorized("ServeHTTP", err))
+		return
+	}
+
+	principal := &Principal{
+		ID:     keyID,
+		Method: "hmac",
+	}
+
+	h.next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+}
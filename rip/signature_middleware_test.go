@@ -0,0 +1,75 @@
+package rip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSignedRequest - creates a signed request
+func newSignedRequest(t *testing.T, signer *Signer, body string) *http.Request {
+
+	r := httptest.NewRequest(http.MethodPost, "/documents?commit=true", bytes.NewBufferString(body))
+	if err := signer.Sign(r); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestSignature(t *testing.T) {
+
+	var received string
+	h, err := NewSignatureMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = string(b)
+	}), &SignatureConfiguration{
+		Keys: map[string]string{"producer": "secret"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	serve := func(r *http.Request) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	signer := NewSigner("producer", "secret")
+
+	r := newSignedRequest(t, signer, `{"id":"1"}`)
+	replay := r.Clone(r.Context())
+	replay.Body = ioutil.NopCloser(bytes.NewBufferString(`{"id":"1"}`))
+
+	assert.Equal(t, http.StatusOK, serve(r))
+	assert.Equal(t, `{"id":"1"}`, received, "the body must be restored")
+	assert.Equal(t, http.StatusUnauthorized, serve(replay), "replays must be rejected")
+
+	tampered := newSignedRequest(t, signer, `{"id":"1"}`)
+	tampered.Body = ioutil.NopCloser(bytes.NewBufferString(`{"id":"2"}`))
+	assert.Equal(t, http.StatusUnauthorized, serve(tampered))
+
+	query := newSignedRequest(t, signer, "")
+	query.URL.RawQuery = "commit=false"
+	assert.Equal(t, http.StatusUnauthorized, serve(query), "the query must be signed")
+
+	reordered := httptest.NewRequest(http.MethodGet, "/documents?b=2&a=1", nil)
+	if !assert.NoError(t, signer.Sign(reordered)) {
+		return
+	}
+	reordered.URL.RawQuery = "a=1&b=2"
+	assert.Equal(t, http.StatusOK, serve(reordered), "the query order must not matter")
+
+	old := newSignedRequest(t, signer, "")
+	old.Header.Set(headerSignatureTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	assert.Equal(t, http.StatusUnauthorized, serve(old))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(newSignedRequest(t, NewSigner("producer", "other"), "")))
+}
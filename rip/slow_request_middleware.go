@@ -0,0 +1,205 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+	"github.com/uol/logh"
+)
+
+const (
+	metricRequestSlow string       = "http.request.slow"
+	timingsKey        dummyKeyType = 5
+	slowRequestMsg                 = "slow request"
+
+	defaultSlowThreshold = time.Second
+)
+
+// SlowRequestConfiguration - configures the slow request middleware
+type SlowRequestConfiguration struct {
+
+	// Threshold - the duration from which the requests are reported, overridden per route by the SlowThreshold option (1 second by default)
+	Threshold funks.Duration
+}
+
+// SlowThreshold - sets the duration from which the route requests are reported by the slow request middleware
+func SlowThreshold(threshold time.Duration) RouteOption {
+	return func(route *Route) {
+		route.SlowThreshold = threshold
+	}
+}
+
+// requestTimings - the durations of the request phases recorded by the handlers
+type requestTimings struct {
+	mutex  sync.Mutex
+	phases map[string]time.Duration
+}
+
+// AddTiming - adds the duration of a request phase (a Solr query, for example) to the slow request report
+func AddTiming(ctx context.Context, phase string, d time.Duration) {
+
+	if timings, ok := ctx.Value(timingsKey).(*requestTimings); ok {
+		timings.mutex.Lock()
+		timings.phases[phase] += d
+		timings.mutex.Unlock()
+	}
+}
+
+// SlowRequestHandler - logs and counts the requests slower than the threshold
+type SlowRequestHandler struct {
+	next      http.Handler
+	threshold time.Duration
+	stats     StatisticsInterface
+	logger    *logh.ContextualLogger
+}
+
+// NewSlowRequestMiddleware - creates a new instance of SlowRequestHandler, it must wrap the Router to know the routes (statisticsImpl can be nil)
+func NewSlowRequestMiddleware(next http.Handler, configuration *SlowRequestConfiguration, statisticsImpl StatisticsInterface) (*SlowRequestHandler, error) {
+
+	if configuration == nil {
+		configuration = &SlowRequestConfiguration{}
+	}
+
+	if configuration.Threshold.Duration < 0 {
+		return nil, errors.New("negative threshold")
+	}
+
+	h := &SlowRequestHandler{
+		next:      next,
+		threshold: configuration.Threshold.Duration,
+		stats:     statisticsImpl,
+		logger:    logh.CreateContextualLogger("pkg", "rip", "type", "slow"),
+	}
+
+	if h.threshold == 0 {
+		h.threshold = defaultSlowThreshold
+	}
+
+	return h, nil
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *SlowRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	start := time.Now()
+
+	r, holder := withRouteHolder(r)
+	r, userTags := withStatsTags(r)
+
+	timings := &requestTimings{
+		phases: map[string]time.Duration{},
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), timingsKey, timings))
+
+	slowResponseWriter := &slowResponseWriter{
+		LogResponseWriter: LogResponseWriter{ResponseWriter: w},
+	}
+
+	h.next.ServeHTTP(slowResponseWriter, r)
+
+	duration := time.Since(start)
+
+	threshold := h.threshold
+	route := r.URL.Path
+
+	if holder.route != nil {
+		route = holder.route.Path
+		if holder.route.SlowThreshold > 0 {
+			threshold = holder.route.SlowThreshold
+		}
+	}
+
+	if duration < threshold {
+		return
+	}
+
+	if h.stats != nil {
+		tags := []interface{}{tagMethod, r.Method, tagPath, route}
+		if len(*userTags)%2 == 0 {
+			for i := 0; i < len(*userTags); i += 2 {
+				if key, ok := (*userTags)[i].(string); ok && key == tagPath {
+					tags[3] = (*userTags)[i+1]
+					continue
+				}
+				tags = append(tags, (*userTags)[i], (*userTags)[i+1])
+			}
+		}
+		h.stats.Increment(metricRequestSlow, tags...)
+	}
+
+	if !logh.WarnEnabled {
+		return
+	}
+
+	status := slowResponseWriter.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	params := make(map[string]string, len(holder.params))
+	for _, param := range holder.params {
+		params[param.Key] = param.Value
+	}
+
+	tags := make(map[string]interface{}, len(*userTags)/2)
+	for i := 0; i+1 < len(*userTags); i += 2 {
+		if key, ok := (*userTags)[i].(string); ok {
+			tags[key] = (*userTags)[i+1]
+		}
+	}
+
+	phases := map[string]float64{}
+	timings.mutex.Lock()
+	for phase, d := range timings.phases {
+		phases[phase] = float64(d) / float64(time.Millisecond)
+	}
+	timings.mutex.Unlock()
+
+	ev := gobol.LogWithRequestID(r.Context(), h.logger.Warn()).
+		Str("method", r.Method).
+		Str("route", route).
+		Str("path", r.URL.Path).
+		Interface("params", params).
+		Int("status", status).
+		Dur("duration", duration).
+		Dur("threshold", threshold).
+		Interface("phases", phases).
+		Interface("tags", tags)
+
+	if !slowResponseWriter.firstByte.IsZero() {
+		ev = ev.Dur("first_byte", slowResponseWriter.firstByte.Sub(start)).
+			Dur("write", start.Add(duration).Sub(slowResponseWriter.firstByte))
+	}
+
+	ev.Msg(slowRequestMsg)
+}
+
+// slowResponseWriter - records when the response starts
+type slowResponseWriter struct {
+	LogResponseWriter
+	firstByte time.Time
+}
+
+func (w *slowResponseWriter) WriteHeader(s int) {
+
+	if w.firstByte.IsZero() {
+		w.firstByte = time.Now()
+	}
+
+	w.LogResponseWriter.WriteHeader(s)
+}
+
+func (w *slowResponseWriter) Write(b []byte) (int, error) {
+
+	if w.firstByte.IsZero() {
+		w.firstByte = time.Now()
+	}
+
+	return w.LogResponseWriter.Write(b)
+}
@@ -0,0 +1,53 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+)
+
+func TestSlowRequest(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	router := NewRouter()
+
+	router.GET("/facets/:collection", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		time.Sleep(5 * time.Millisecond)
+		AddTiming(r.Context(), "solr", 5*time.Millisecond)
+		AddStatsTags(r, "collection", ps.ByName("collection"))
+		if ps.ByName("collection") == "path" {
+			AddStatsTags(r, tagPath, "/facets")
+		}
+		return nil
+	}, SlowThreshold(time.Millisecond))
+
+	router.GET("/search", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	h, err := NewSlowRequestMiddleware(router, &SlowRequestConfiguration{Threshold: funks.Duration{Duration: time.Minute}}, stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search", nil))
+	assert.Equal(t, 0, stats.increments[metricRequestSlow], "below the default threshold")
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/facets/news", nil))
+	assert.Equal(t, 1, stats.increments[metricRequestSlow], "above the route threshold")
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/facets/:collection", "collection", "news"}, stats.tags[metricRequestSlow])
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/facets/path", nil))
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/facets", "collection", "path"}, stats.tags[metricRequestSlow], "a path tag replaces the route")
+}
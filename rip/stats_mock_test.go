@@ -0,0 +1,21 @@
+package rip
+
+import "sync"
+
+// statsMock - records the incremented metrics
+type statsMock struct {
+	mutex      sync.Mutex
+	increments map[string]int
+	tags       map[string][]interface{}
+}
+
+func (s *statsMock) Increment(metric string, tags ...interface{}) {
+	s.mutex.Lock()
+	s.increments[metric]++
+	if s.tags != nil {
+		s.tags[metric] = tags
+	}
+	s.mutex.Unlock()
+}
+
+func (s *statsMock) Maximum(metric string, value float64, tags ...interface{}) {}
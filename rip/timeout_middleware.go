@@ -0,0 +1,180 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/uol/funks"
+)
+
+const (
+	metricRequestTimeout string = "http.request.timeout"
+	msgRequestTimeout    string = "request timeout"
+)
+
+// TimeoutConfiguration - configures the timeout middleware
+type TimeoutConfiguration struct {
+
+	// Timeout - the request deadline
+	Timeout funks.Duration
+
+	// StatusCode - the status sent on timeouts, 503 (default) or 504
+	StatusCode int
+}
+
+// timeoutResponseWriter - passes the writes through until the deadline, discarding the late ones
+type timeoutResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	mutex       sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutResponseWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutResponseWriter) writeHeader(status int) {
+
+	if tw.wroteHeader {
+		return
+	}
+
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+
+	tw.wroteHeader = true
+	tw.w.WriteHeader(status)
+}
+
+func (tw *timeoutResponseWriter) WriteHeader(status int) {
+
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+
+	tw.writeHeader(status)
+}
+
+func (tw *timeoutResponseWriter) Write(b []byte) (int, error) {
+
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.writeHeader(http.StatusOK)
+
+	return tw.w.Write(b)
+}
+
+// TimeoutHandler - sets a deadline to the request, failing if the handler has not written until there
+type TimeoutHandler struct {
+	next       http.Handler
+	timeout    funks.Duration
+	statusCode int
+	stats      StatisticsInterface
+}
+
+// NewTimeoutMiddleware - creates a new instance of TimeoutHandler, wrap a single route handler to configure it per route (statisticsImpl can be nil)
+func NewTimeoutMiddleware(next http.Handler, configuration *TimeoutConfiguration, statisticsImpl StatisticsInterface) *TimeoutHandler {
+
+	if configuration == nil {
+		configuration = &TimeoutConfiguration{}
+	}
+
+	statusCode := configuration.StatusCode
+	if statusCode != http.StatusGatewayTimeout {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	return &TimeoutHandler{
+		next:       next,
+		timeout:    configuration.Timeout,
+		statusCode: statusCode,
+		stats:      statisticsImpl,
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *TimeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if h.timeout.Duration <= 0 {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	r, holder := withRouteHolder(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout.Duration)
+	defer cancel()
+
+	tw := &timeoutResponseWriter{
+		w:      w,
+		header: http.Header{},
+	}
+
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+		h.next.ServeHTTP(tw, r.WithContext(ctx))
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		// the handler may have only set headers or written nothing
+		tw.mutex.Lock()
+		tw.writeHeader(http.StatusOK)
+		tw.mutex.Unlock()
+		return
+	case <-ctx.Done():
+	}
+
+	tw.mutex.Lock()
+
+	if tw.wroteHeader {
+		tw.mutex.Unlock()
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+		}
+		return
+	}
+
+	tw.timedOut = true
+	tw.mutex.Unlock()
+
+	if ctx.Err() == context.Canceled {
+		return
+	}
+
+	if h.stats != nil {
+		h.stats.Increment(metricRequestTimeout, tagMethod, r.Method, tagPath, routePattern(r, holder))
+	}
+
+	FailContext(
+		r.Context(),
+		w,
+		errBasic("rip", "ServeHTTP", msgRequestTimeout, h.statusCode, errors.New(http.StatusText(h.statusCode))),
+	)
+}
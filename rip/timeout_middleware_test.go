@@ -0,0 +1,100 @@
+package rip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/gobol"
+)
+
+// serveWithTimeout - serves a request through the timeout middleware
+func serveWithTimeout(handler http.HandlerFunc, statusCode int) *httptest.ResponseRecorder {
+
+	h := NewTimeoutMiddleware(handler, &TimeoutConfiguration{
+		Timeout:    *funks.ForceNewStringDuration("50ms"),
+		StatusCode: statusCode,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/facets", nil))
+
+	return w
+}
+
+func TestTimeoutNotReached(t *testing.T) {
+
+	w := serveWithTimeout(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "ok")
+		w.WriteHeader(http.StatusCreated)
+	}, 0)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "ok", w.Header().Get("X-Test"))
+}
+
+func TestTimeoutReached(t *testing.T) {
+
+	lateWrite := make(chan error, 1)
+
+	w := serveWithTimeout(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("late"))
+		lateWrite <- err
+	}, http.StatusGatewayTimeout)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), msgRequestTimeout)
+	assert.Equal(t, http.ErrHandlerTimeout, <-lateWrite)
+	assert.NotContains(t, w.Body.String(), "late")
+}
+
+func TestTimeoutHeadersOnly(t *testing.T) {
+
+	w := serveWithTimeout(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "ok")
+	}, 0)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Header().Get("X-Test"), "the headers must be flushed when the handler does not write")
+}
+
+func TestTimeoutNilConfiguration(t *testing.T) {
+
+	h := NewTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), nil, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/facets", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, h.statusCode)
+}
+
+func TestTimeoutMetricRoute(t *testing.T) {
+
+	stats := &statsMock{
+		increments: map[string]int{},
+		tags:       map[string][]interface{}{},
+	}
+
+	router := NewRouter()
+	router.GET("/facets/:collection", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) gobol.Error {
+		<-r.Context().Done()
+		return nil
+	})
+
+	h := NewTimeoutMiddleware(router, &TimeoutConfiguration{Timeout: *funks.ForceNewStringDuration("20ms")}, stats)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/facets/news", nil))
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	assert.Equal(t, []interface{}{tagMethod, http.MethodGet, tagPath, "/facets/:collection"}, stats.tags[metricRequestTimeout], "the metric must be tagged with the route pattern")
+}
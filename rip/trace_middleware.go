@@ -0,0 +1,55 @@
+package rip
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/uol/gobol/tracing"
+)
+
+// TraceHandler - creates a server span per request, continuing the W3C trace context received
+type TraceHandler struct {
+	next http.Handler
+}
+
+// NewTraceMiddleware - creates a new instance of TraceHandler
+func NewTraceMiddleware(next http.Handler) *TraceHandler {
+
+	return &TraceHandler{
+		next: next,
+	}
+}
+
+// ServeHTTP - implements the interface to serve http requests
+func (h *TraceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ctx := tracing.Extract(r.Context(), r.Header)
+	ctx, span := tracing.StartKind(ctx, "HTTP "+r.Method, tracing.KindServer)
+
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.target", r.URL.Path)
+	span.SetAttribute("http.user_agent", r.Header.Get(headerUserAgent))
+
+	if requestID := RequestID(r); requestID != "" {
+		span.SetAttribute("request_id", requestID)
+	}
+
+	logResponseWriter := &LogResponseWriter{
+		ResponseWriter: w,
+	}
+
+	h.next.ServeHTTP(logResponseWriter, r.WithContext(ctx))
+
+	status := logResponseWriter.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	span.SetAttribute("http.status_code", status)
+
+	if status >= http.StatusInternalServerError {
+		span.SetError(errors.New(http.StatusText(status)))
+	}
+
+	span.Finish()
+}
@@ -0,0 +1,49 @@
+package rip
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol/tracing"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	tracing.Configure(tracing.NewJSONExporter(buffer))
+	defer tracing.Configure()
+
+	var traceID string
+
+	h := NewTraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if span := tracing.FromContext(r.Context()); span != nil {
+			traceID = span.Context.TraceID.String()
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/facets", nil)
+	r.Header.Set(tracing.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID, "the handler must see the continued trace")
+
+	exported := map[string]interface{}{}
+	if !assert.NoError(t, json.Unmarshal(buffer.Bytes(), &exported)) {
+		return
+	}
+
+	assert.Equal(t, "HTTP GET", exported["name"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exported["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", exported["parentId"], "the incoming span must be the parent")
+	assert.NotEmpty(t, exported["error"], "server errors must be recorded")
+
+	if attributes, ok := exported["attributes"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Equal(t, float64(http.StatusServiceUnavailable), attributes["http.status_code"])
+		assert.Equal(t, "/facets", attributes["http.target"])
+	}
+}
@@ -0,0 +1,276 @@
+package rip
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/uol/gobol"
+)
+
+const (
+	msgUnsupportedMediaType = "unsupported media type"
+	msgFileTooLarge         = "file too large"
+	msgTooManyFiles         = "too many files"
+	msgInvalidMultipart     = "invalid multipart body"
+
+	mediaTypeMultipart   = "multipart/form-data"
+	mediaTypeOctetStream = "application/octet-stream"
+
+	defaultUploadMaxFileSize  = 10 << 20
+	defaultUploadMaxTotalSize = 100 << 20
+	defaultUploadMaxFiles     = 10
+	defaultUploadMaxFieldSize = 1 << 20
+)
+
+var (
+	errFileTooLarge  = errors.New("file too large")
+	errTotalTooLarge = errors.New("request too large")
+)
+
+// UploadConfiguration - configures the multipart uploads
+type UploadConfiguration struct {
+
+	// MaxFileSize - the maximum size of each file in bytes (10MB by default)
+	MaxFileSize int64
+
+	// MaxTotalSize - the maximum size of the request body in bytes (100MB by default)
+	MaxTotalSize int64
+
+	// MaxFiles - the maximum number of files (10 by default)
+	MaxFiles int
+
+	// MaxFieldSize - the maximum size of each non file field in bytes (1MB by default)
+	MaxFieldSize int64
+
+	// AllowedTypes - the file content types allowed, "type/*" matches any subtype and empty allows all
+	AllowedTypes []string
+}
+
+// UploadedFile - a file streamed from the request body, it is valid only while the FileHandler runs
+type UploadedFile struct {
+	io.Reader
+
+	// FieldName - the form field name
+	FieldName string
+
+	// FileName - the file name sent by the client, it must not be trusted as a path
+	FileName string
+
+	// ContentType - the content type sent by the client, without parameters
+	ContentType string
+}
+
+// FileHandler - processes a file, the unread remainder is discarded
+type FileHandler func(file *UploadedFile) gobol.Error
+
+// Uploader - streams the multipart files enforcing the limits, nothing is buffered in memory or disk
+type Uploader struct {
+	maxFileSize  int64
+	maxTotalSize int64
+	maxFiles     int
+	maxFieldSize int64
+	allowedTypes []string
+}
+
+// NewUploader - creates a new uploader
+func NewUploader(configuration *UploadConfiguration) (*Uploader, error) {
+
+	if configuration == nil {
+		configuration = &UploadConfiguration{}
+	}
+
+	if configuration.MaxFileSize < 0 || configuration.MaxTotalSize < 0 || configuration.MaxFiles < 0 || configuration.MaxFieldSize < 0 {
+		return nil, errors.New("negative upload limits")
+	}
+
+	u := &Uploader{
+		maxFileSize:  configuration.MaxFileSize,
+		maxTotalSize: configuration.MaxTotalSize,
+		maxFiles:     configuration.MaxFiles,
+		maxFieldSize: configuration.MaxFieldSize,
+	}
+
+	if u.maxFileSize == 0 {
+		u.maxFileSize = defaultUploadMaxFileSize
+	}
+
+	if u.maxTotalSize == 0 {
+		u.maxTotalSize = defaultUploadMaxTotalSize
+	}
+
+	if u.maxFiles == 0 {
+		u.maxFiles = defaultUploadMaxFiles
+	}
+
+	if u.maxFieldSize == 0 {
+		u.maxFieldSize = defaultUploadMaxFieldSize
+	}
+
+	for _, allowed := range configuration.AllowedTypes {
+		u.allowedTypes = append(u.allowedTypes, strings.ToLower(strings.TrimSpace(allowed)))
+	}
+
+	return u, nil
+}
+
+// allowed - checks if the content type is in the allow-list
+func (u *Uploader) allowed(contentType string) bool {
+
+	if len(u.allowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range u.allowedTypes {
+		if allowed == contentType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitedReader - fails with the error when more than limit bytes are read
+type limitedReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	err      error
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+
+	if l.exceeded {
+		return 0, l.err
+	}
+
+	n, err := l.reader.Read(p)
+	l.read += int64(n)
+
+	if l.read > l.limit {
+		l.exceeded = true
+		return n - int(l.read-l.limit), l.err
+	}
+
+	return n, err
+}
+
+// errUpload - returns an upload error
+func errUpload(message string, code int, e error) gobol.Error {
+	return errBasic("rip", "Stream", message, code, e)
+}
+
+// Stream - calls the handler for each file of the multipart body in order, returning the other form fields;
+// the errors are suitable for Fail: 413 for sizes, 415 for content types and 400 for malformed bodies
+func (u *Uploader) Stream(r *http.Request, handler FileHandler) (url.Values, gobol.Error) {
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(headerContentType))
+	if err != nil || mediaType != mediaTypeMultipart {
+		return nil, errUpload(msgUnsupportedMediaType, http.StatusUnsupportedMediaType, errors.New("expected "+mediaTypeMultipart))
+	}
+
+	if r.ContentLength > u.maxTotalSize {
+		return nil, errUpload(msgPayloadTooLarge, http.StatusRequestEntityTooLarge, errTotalTooLarge)
+	}
+
+	total := &limitedReader{
+		reader: r.Body,
+		limit:  u.maxTotalSize,
+		err:    errTotalTooLarge,
+	}
+
+	r.Body = ioutil.NopCloser(total)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errUpload(msgInvalidMultipart, http.StatusBadRequest, err)
+	}
+
+	fields := url.Values{}
+	files := 0
+
+	for {
+
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fields, nil
+		}
+
+		if err != nil {
+			if total.exceeded {
+				return nil, errUpload(msgPayloadTooLarge, http.StatusRequestEntityTooLarge, err)
+			}
+			return nil, errUpload(msgInvalidMultipart, http.StatusBadRequest, err)
+		}
+
+		if part.FileName() == "" {
+
+			value, err := ioutil.ReadAll(&limitedReader{reader: part, limit: u.maxFieldSize, err: errFileTooLarge})
+			part.Close()
+			if err != nil {
+				if total.exceeded {
+					return nil, errUpload(msgPayloadTooLarge, http.StatusRequestEntityTooLarge, err)
+				}
+				if err == errFileTooLarge {
+					return nil, errUpload(msgPayloadTooLarge, http.StatusRequestEntityTooLarge, errors.New("field too large: "+part.FormName()))
+				}
+				return nil, errUpload(msgInvalidMultipart, http.StatusBadRequest, err)
+			}
+
+			fields.Add(part.FormName(), string(value))
+			continue
+		}
+
+		files++
+		if files > u.maxFiles {
+			part.Close()
+			return nil, errUpload(msgTooManyFiles, http.StatusRequestEntityTooLarge, errors.New(msgTooManyFiles))
+		}
+
+		contentType := mediaTypeOctetStream
+		if declared := part.Header.Get(headerContentType); declared != "" {
+			if parsed, _, err := mime.ParseMediaType(declared); err == nil {
+				contentType = strings.ToLower(parsed)
+			}
+		}
+
+		if !u.allowed(contentType) {
+			part.Close()
+			return nil, errUpload(msgUnsupportedMediaType, http.StatusUnsupportedMediaType, errors.New("content type not allowed: "+contentType))
+		}
+
+		file := &limitedReader{
+			reader: part,
+			limit:  u.maxFileSize,
+			err:    errFileTooLarge,
+		}
+
+		gerr := handler(&UploadedFile{
+			Reader:      file,
+			FieldName:   part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: contentType,
+		})
+
+		if gerr == nil {
+			io.Copy(ioutil.Discard, file)
+		}
+
+		part.Close()
+
+		// the limits take precedence over the handler errors caused by them
+		switch {
+		case total.exceeded:
+			return nil, errUpload(msgPayloadTooLarge, http.StatusRequestEntityTooLarge, errTotalTooLarge)
+		case file.exceeded:
+			return nil, errUpload(msgFileTooLarge, http.StatusRequestEntityTooLarge, errors.New(msgFileTooLarge+": "+part.FileName()))
+		case gerr != nil:
+			return nil, gerr
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package rip
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gobol"
+)
+
+// multipartRequest - builds a multipart request with the fields and the files (name, content type and content)
+func multipartRequest(fields map[string]string, files ...[3]string) *http.Request {
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="`+file[0]+`"`)
+		header.Set(headerContentType, file[1])
+		part, _ := writer.CreatePart(header)
+		part.Write([]byte(file[2]))
+	}
+
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/import", body)
+	r.Header.Set(headerContentType, writer.FormDataContentType())
+
+	return r
+}
+
+func TestUploaderStream(t *testing.T) {
+
+	u, err := NewUploader(&UploadConfiguration{
+		MaxFileSize:  10,
+		MaxTotalSize: 1024,
+		MaxFiles:     2,
+		AllowedTypes: []string{"text/csv", "application/*"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	contents := []string{}
+	collect := func(file *UploadedFile) gobol.Error {
+		b, err := ioutil.ReadAll(file)
+		if err != nil {
+			return errBasic("rip", "collect", "error reading", http.StatusBadRequest, err)
+		}
+		contents = append(contents, file.FileName+":"+file.ContentType+":"+string(b))
+		return nil
+	}
+
+	fields, gerr := u.Stream(multipartRequest(map[string]string{"collection": "news"},
+		[3]string{"a.csv", "text/csv; charset=utf-8", "id,title"},
+		[3]string{"b.json", "application/json", `{"id":1}`},
+	), collect)
+	if assert.Nil(t, gerr) {
+		assert.Equal(t, "news", fields.Get("collection"))
+		assert.Equal(t, []string{"a.csv:text/csv:id,title", `b.json:application/json:{"id":1}`}, contents)
+	}
+
+	_, gerr = u.Stream(multipartRequest(nil, [3]string{"a.csv", "text/csv", "01234567890"}), collect)
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, gerr.StatusCode())
+		assert.Equal(t, msgFileTooLarge, gerr.Message())
+	}
+
+	_, gerr = u.Stream(multipartRequest(nil, [3]string{"a.csv", "text/csv", "01234567890"}), func(file *UploadedFile) gobol.Error {
+		return nil
+	})
+	if assert.NotNil(t, gerr, "the unread remainder must be checked") {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, gerr.StatusCode())
+	}
+
+	_, gerr = u.Stream(multipartRequest(nil, [3]string{"a.png", "image/png", "png"}), collect)
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, gerr.StatusCode())
+	}
+
+	_, gerr = u.Stream(multipartRequest(nil,
+		[3]string{"a.csv", "text/csv", "a"},
+		[3]string{"b.csv", "text/csv", "b"},
+		[3]string{"c.csv", "text/csv", "c"},
+	), collect)
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, msgTooManyFiles, gerr.Message())
+	}
+
+	_, gerr = u.Stream(multipartRequest(map[string]string{"big": strings.Repeat("x", 2048)}), collect)
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, gerr.StatusCode())
+	}
+
+	_, gerr = u.Stream(multipartRequest(nil, [3]string{"a.csv", "text/csv", "a"}), func(file *UploadedFile) gobol.Error {
+		return errBasic("rip", "handler", "invalid csv", http.StatusUnprocessableEntity, errors.New("invalid csv"))
+	})
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, gerr.StatusCode())
+	}
+
+	_, gerr = u.Stream(httptest.NewRequest(http.MethodPost, "/import", strings.NewReader("{}")), collect)
+	if assert.NotNil(t, gerr) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, gerr.StatusCode())
+	}
+}
@@ -1,6 +1,7 @@
 package solar
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -78,7 +79,7 @@ func (ss *SolrService) AddNewField(collection, name, fieldType string, multiValu
 // getSchema - returns the schema instance from a collection
 func (ss *SolrService) getSchema(collection string) (*solr.Schema, error) {
 
-	si, err := ss.getSolrInterface(collection)
+	si, err := ss.getSolrInterface(context.Background(), collection)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,81 @@
+package solar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+/**
+* Contains the health check functions.
+**/
+
+const cPingOK string = "OK"
+
+// Ping - pings the collection
+func (ss *SolrService) Ping(ctx context.Context, collection string) error {
+
+	ctx, span := ss.startSpan(ctx, "Ping", collection)
+	err := ss.ping(ctx, collection)
+	span.FinishWithError(err)
+
+	return err
+}
+
+// ping - pings the collection
+func (ss *SolrService) ping(ctx context.Context, collection string) error {
+
+	si, err := ss.getSolrInterface(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	status, _, err := si.Ping()
+	if err != nil {
+		return err
+	}
+
+	if status != cPingOK {
+		return fmt.Errorf("collection %s ping status: %s", collection, status)
+	}
+
+	return nil
+}
+
+// HealthChecker - checks the solr collections with ping (satisfies the rip.HealthChecker interface)
+type HealthChecker struct {
+	ss          *SolrService
+	collections []string
+}
+
+// NewHealthChecker - creates a new health checker pinging each collection
+func (ss *SolrService) NewHealthChecker(collections ...string) *HealthChecker {
+
+	return &HealthChecker{
+		ss:          ss,
+		collections: collections,
+	}
+}
+
+// Name - returns the component name
+func (hc *HealthChecker) Name() string {
+	return "solr"
+}
+
+// Check - pings all collections
+func (hc *HealthChecker) Check(ctx context.Context) error {
+
+	failed := []string{}
+
+	for _, collection := range hc.collections {
+		if err := hc.ss.Ping(ctx, collection); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", collection, err.Error()))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("collections not available: %s", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
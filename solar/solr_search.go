@@ -1,6 +1,7 @@
 package solar
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -16,7 +17,7 @@ import (
 // buildBasicQuery - builds a basic query
 func (ss *SolrService) buildBasicQuery(collection, query, fields string, start, rows int) *solr.Query {
 
-	defer ss.recoverFromFailure()
+	defer ss.recoverFromFailure(context.Background())
 
 	q := solr.NewQuery()
 	q.Q(query)
@@ -34,7 +35,7 @@ func (ss *SolrService) buildBasicQuery(collection, query, fields string, start,
 // builFilteredQuery - builds a basic query
 func (ss *SolrService) buildFilteredQuery(collection, query, fields string, start, rows int, filterQueries []string) *solr.Query {
 
-	defer ss.recoverFromFailure()
+	defer ss.recoverFromFailure(context.Background())
 
 	q := ss.buildBasicQuery(collection, query, fields, start, rows)
 
@@ -50,9 +51,25 @@ func (ss *SolrService) buildFilteredQuery(collection, query, fields string, star
 // SimpleQuery - queries the solr
 func (ss *SolrService) SimpleQuery(collection, query, fields string, start, rows int) (*solr.SolrResult, error) {
 
-	defer ss.recoverFromFailure()
+	return ss.SimpleQueryContext(context.Background(), collection, query, fields, start, rows)
+}
+
+// SimpleQueryContext - queries the solr using the request context
+func (ss *SolrService) SimpleQueryContext(ctx context.Context, collection, query, fields string, start, rows int) (*solr.SolrResult, error) {
+
+	ctx, span := ss.startSpan(ctx, "SimpleQuery", collection)
+	r, err := ss.simpleQuery(ctx, collection, query, fields, start, rows)
+	span.FinishWithError(err)
+
+	return r, err
+}
+
+// simpleQuery - queries the solr
+func (ss *SolrService) simpleQuery(ctx context.Context, collection, query, fields string, start, rows int) (*solr.SolrResult, error) {
 
-	si, err := ss.getSolrInterface(collection)
+	defer ss.recoverFromFailure(ctx)
+
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +83,7 @@ func (ss *SolrService) SimpleQuery(collection, query, fields string, start, rows
 
 	if r.Status != 0 {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
+			ss.logError(ctx).Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
 		}
 		return nil, fmt.Errorf("received a non ok status: %d", r.Status)
 	}
@@ -77,9 +94,25 @@ func (ss *SolrService) SimpleQuery(collection, query, fields string, start, rows
 // FilteredQuery - queries the solr
 func (ss *SolrService) FilteredQuery(collection, query, fields string, start, rows int, filterQueries []string) (*solr.SolrResult, error) {
 
-	defer ss.recoverFromFailure()
+	return ss.FilteredQueryContext(context.Background(), collection, query, fields, start, rows, filterQueries)
+}
+
+// FilteredQueryContext - queries the solr using the request context
+func (ss *SolrService) FilteredQueryContext(ctx context.Context, collection, query, fields string, start, rows int, filterQueries []string) (*solr.SolrResult, error) {
 
-	si, err := ss.getSolrInterface(collection)
+	ctx, span := ss.startSpan(ctx, "FilteredQuery", collection)
+	r, err := ss.filteredQuery(ctx, collection, query, fields, start, rows, filterQueries)
+	span.FinishWithError(err)
+
+	return r, err
+}
+
+// filteredQuery - queries the solr
+func (ss *SolrService) filteredQuery(ctx context.Context, collection, query, fields string, start, rows int, filterQueries []string) (*solr.SolrResult, error) {
+
+	defer ss.recoverFromFailure(ctx)
+
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +126,7 @@ func (ss *SolrService) FilteredQuery(collection, query, fields string, start, ro
 
 	if r.Status != 0 {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
+			ss.logError(ctx).Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
 		}
 		return nil, fmt.Errorf("received a non ok status: %d", r.Status)
 	}
@@ -126,9 +159,25 @@ const cFacetLimit string = "facet.limit"
 // Facets - get facets from solr
 func (ss *SolrService) Facets(collection, query, fields string, start, rows int, filterQueries []string, facetFields, childrenFacetFields []string, blockJoin bool, facetLimit, minCount int) (*solr.SolrResult, error) {
 
-	defer ss.recoverFromFailure()
+	return ss.FacetsContext(context.Background(), collection, query, fields, start, rows, filterQueries, facetFields, childrenFacetFields, blockJoin, facetLimit, minCount)
+}
+
+// FacetsContext - get facets from solr using the request context
+func (ss *SolrService) FacetsContext(ctx context.Context, collection, query, fields string, start, rows int, filterQueries []string, facetFields, childrenFacetFields []string, blockJoin bool, facetLimit, minCount int) (*solr.SolrResult, error) {
+
+	ctx, span := ss.startSpan(ctx, "Facets", collection)
+	r, err := ss.facets(ctx, collection, query, fields, start, rows, filterQueries, facetFields, childrenFacetFields, blockJoin, facetLimit, minCount)
+	span.FinishWithError(err)
+
+	return r, err
+}
+
+// facets - get facets from solr
+func (ss *SolrService) facets(ctx context.Context, collection, query, fields string, start, rows int, filterQueries []string, facetFields, childrenFacetFields []string, blockJoin bool, facetLimit, minCount int) (*solr.SolrResult, error) {
+
+	defer ss.recoverFromFailure(ctx)
 
-	si, err := ss.getSolrInterface(collection)
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +203,7 @@ func (ss *SolrService) Facets(collection, query, fields string, start, rows int,
 
 	if r.Status != 0 {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
+			ss.logError(ctx).Msg(fmt.Sprintf("received a non ok status: %d", r.Status))
 		}
 		return nil, fmt.Errorf("received a non ok status: %d", r.Status)
 	}
@@ -1,6 +1,7 @@
 package solar
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -10,7 +11,10 @@ import (
 
 	"github.com/uol/funks"
 
+	"github.com/rs/zerolog"
 	"github.com/uol/go-solr/solr"
+	"github.com/uol/gobol"
+	"github.com/uol/gobol/tracing"
 	"github.com/uol/logh"
 )
 
@@ -30,14 +34,30 @@ type SolrService struct {
 }
 
 // recoverFromFailure - recovers from a failure
-func (ss *SolrService) recoverFromFailure() {
+func (ss *SolrService) recoverFromFailure(ctx context.Context) {
 	if r := recover(); r != nil {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Msg(fmt.Sprintf("recovered from: %s", r))
+			ss.logError(ctx).Msg(fmt.Sprintf("recovered from: %s", r))
 		}
 	}
 }
 
+// logError - returns the error event logger including the request id found in the context
+func (ss *SolrService) logError(ctx context.Context) *zerolog.Event {
+
+	return gobol.LogWithRequestID(ctx, ss.loggers.Error())
+}
+
+// startSpan - starts a client span for a solr operation
+func (ss *SolrService) startSpan(ctx context.Context, operation, collection string) (context.Context, *tracing.Span) {
+
+	ctx, span := tracing.StartKind(ctx, "solr."+operation, tracing.KindClient)
+	span.SetAttribute("solr.url", ss.url)
+	span.SetAttribute("solr.collection", collection)
+
+	return ctx, span
+}
+
 // HTTPClient - the http client configuration
 type HTTPClient struct {
 	Timeout                    funks.Duration
@@ -84,7 +104,7 @@ func NewSolrService(configuration *Configuration) (*SolrService, error) {
 }
 
 // getSolrInterface - creates a new solr interface based on the given collection
-func (ss *SolrService) getSolrInterface(collection string) (*solr.SolrInterface, error) {
+func (ss *SolrService) getSolrInterface(ctx context.Context, collection string) (*solr.SolrInterface, error) {
 
 	if si, ok := ss.solrInterfaceCache.Load(collection); ok {
 		return si.(*solr.SolrInterface), nil
@@ -93,7 +113,7 @@ func (ss *SolrService) getSolrInterface(collection string) (*solr.SolrInterface,
 	si, err := solr.NewSolrInterface(ss.url, collection, ss.queryClient, ss.updateClient)
 	if err != nil {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Err(err).Msg("error creating a new instance of solr interface")
+			ss.logError(ctx).Err(err).Msg("error creating a new instance of solr interface")
 		}
 		return nil, err
 	}
@@ -113,16 +133,32 @@ const (
 // AddDocument - add one document to the solr collection
 func (ss *SolrService) AddDocument(collection string, commit bool, doc *solr.Document) error {
 
-	defer ss.recoverFromFailure()
+	return ss.AddDocumentContext(context.Background(), collection, commit, doc)
+}
+
+// AddDocumentContext - add one document to the solr collection using the request context
+func (ss *SolrService) AddDocumentContext(ctx context.Context, collection string, commit bool, doc *solr.Document) error {
+
+	ctx, span := ss.startSpan(ctx, "AddDocument", collection)
+	err := ss.addDocument(ctx, collection, commit, doc)
+	span.FinishWithError(err)
+
+	return err
+}
+
+// addDocument - add one document to the solr collection
+func (ss *SolrService) addDocument(ctx context.Context, collection string, commit bool, doc *solr.Document) error {
+
+	defer ss.recoverFromFailure(ctx)
 
 	if doc == nil {
 		return errors.New("document is null")
 	}
 
-	si, err := ss.getSolrInterface(collection)
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Err(err).Msg("error getting solr interface")
+			ss.logError(ctx).Err(err).Msg("error getting solr interface")
 		}
 		return err
 	}
@@ -155,16 +191,32 @@ func (ss *SolrService) AddDocument(collection string, commit bool, doc *solr.Doc
 // AddDocuments - add one or more documentos to the solr collection
 func (ss *SolrService) AddDocuments(collection string, commit bool, docs ...solr.Document) error {
 
-	defer ss.recoverFromFailure()
+	return ss.AddDocumentsContext(context.Background(), collection, commit, docs...)
+}
+
+// AddDocumentsContext - add one or more documents to the solr collection using the request context
+func (ss *SolrService) AddDocumentsContext(ctx context.Context, collection string, commit bool, docs ...solr.Document) error {
+
+	ctx, span := ss.startSpan(ctx, "AddDocuments", collection)
+	err := ss.addDocuments(ctx, collection, commit, docs...)
+	span.FinishWithError(err)
+
+	return err
+}
+
+// addDocuments - add one or more documents to the solr collection
+func (ss *SolrService) addDocuments(ctx context.Context, collection string, commit bool, docs ...solr.Document) error {
+
+	defer ss.recoverFromFailure(ctx)
 
 	if docs == nil || len(docs) == 0 {
 		return errors.New("no documents to add")
 	}
 
-	si, err := ss.getSolrInterface(collection)
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Err(err).Msg("error getting solr interface")
+			ss.logError(ctx).Err(err).Msg("error getting solr interface")
 		}
 		return err
 	}
@@ -197,7 +249,23 @@ func (ss *SolrService) AddDocuments(collection string, commit bool, docs ...solr
 // DeleteDocumentByID - delete a document by ID
 func (ss *SolrService) DeleteDocumentByID(collection string, commit bool, id string) error {
 
-	defer ss.recoverFromFailure()
+	return ss.DeleteDocumentByIDContext(context.Background(), collection, commit, id)
+}
+
+// DeleteDocumentByIDContext - delete a document by ID using the request context
+func (ss *SolrService) DeleteDocumentByIDContext(ctx context.Context, collection string, commit bool, id string) error {
+
+	ctx, span := ss.startSpan(ctx, "DeleteDocumentByID", collection)
+	err := ss.deleteDocumentByID(ctx, collection, commit, id)
+	span.FinishWithError(err)
+
+	return err
+}
+
+// deleteDocumentByID - delete a document by ID
+func (ss *SolrService) deleteDocumentByID(ctx context.Context, collection string, commit bool, id string) error {
+
+	defer ss.recoverFromFailure(ctx)
 
 	if id == cEmpty {
 		return errors.New("document id not informed, no document will be deleted")
@@ -205,7 +273,7 @@ func (ss *SolrService) DeleteDocumentByID(collection string, commit bool, id str
 
 	query := fmt.Sprintf("id:%s", id)
 
-	err := ss.DeleteDocumentByQuery(collection, commit, query)
+	err := ss.DeleteDocumentByQueryContext(ctx, collection, commit, query)
 	if err != nil {
 		return err
 	}
@@ -216,16 +284,32 @@ func (ss *SolrService) DeleteDocumentByID(collection string, commit bool, id str
 // DeleteDocumentByQuery - delete document by query
 func (ss *SolrService) DeleteDocumentByQuery(collection string, commit bool, query string) error {
 
-	defer ss.recoverFromFailure()
+	return ss.DeleteDocumentByQueryContext(context.Background(), collection, commit, query)
+}
+
+// DeleteDocumentByQueryContext - delete document by query using the request context
+func (ss *SolrService) DeleteDocumentByQueryContext(ctx context.Context, collection string, commit bool, query string) error {
+
+	ctx, span := ss.startSpan(ctx, "DeleteDocumentByQuery", collection)
+	err := ss.deleteDocumentByQuery(ctx, collection, commit, query)
+	span.FinishWithError(err)
+
+	return err
+}
+
+// deleteDocumentByQuery - delete document by query
+func (ss *SolrService) deleteDocumentByQuery(ctx context.Context, collection string, commit bool, query string) error {
+
+	defer ss.recoverFromFailure(ctx)
 
 	if query == cEmpty {
 		return errors.New("query not informed, no document will be deleted")
 	}
 
-	si, err := ss.getSolrInterface(collection)
+	si, err := ss.getSolrInterface(ctx, collection)
 	if err != nil {
 		if logh.ErrorEnabled {
-			ss.loggers.Error().Err(err).Msg("error getting solr interface")
+			ss.logError(ctx).Err(err).Msg("error getting solr interface")
 		}
 		return err
 	}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+/**
+* Contains the pluggable span exporters.
+**/
+
+// Exporter - receives the finished spans
+type Exporter interface {
+
+	// Export - exports a finished span
+	Export(span *Span)
+}
+
+var (
+	exportersMutex sync.RWMutex
+	exporters      []Exporter
+)
+
+// Configure - sets the exporters globally (no exporters disables the exporting)
+func Configure(e ...Exporter) {
+
+	exportersMutex.Lock()
+	defer exportersMutex.Unlock()
+
+	exporters = e
+}
+
+// export - sends the span to all configured exporters
+func export(span *Span) {
+
+	exportersMutex.RLock()
+	defer exportersMutex.RUnlock()
+
+	for _, e := range exporters {
+		e.Export(span)
+	}
+}
+
+// jsonSpan - the span format written by the JSON exporter
+type jsonSpan struct {
+	TraceID    string                 `json:"traceId"`
+	SpanID     string                 `json:"spanId"`
+	ParentID   string                 `json:"parentId,omitempty"`
+	Name       string                 `json:"name"`
+	Kind       Kind                   `json:"kind"`
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	DurationMS float64                `json:"durationMs"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// JSONExporter - writes one JSON line per span, useful for local testing
+type JSONExporter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONExporter - creates a new JSON exporter (use os.Stdout for the stdout exporter)
+func NewJSONExporter(w io.Writer) *JSONExporter {
+
+	return &JSONExporter{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Export - implements the Exporter interface
+func (e *JSONExporter) Export(span *Span) {
+
+	span.mutex.Lock()
+	js := jsonSpan{
+		TraceID:    span.Context.TraceID.String(),
+		SpanID:     span.Context.SpanID.String(),
+		Name:       span.Name,
+		Kind:       span.Kind,
+		Start:      span.Start,
+		End:        span.End,
+		DurationMS: float64(span.End.Sub(span.Start)) / float64(time.Millisecond),
+		Attributes: span.Attributes,
+		Error:      span.Error,
+	}
+	span.mutex.Unlock()
+
+	if span.ParentID.IsValid() {
+		js.ParentID = span.ParentID.String()
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.encoder.Encode(js)
+}
@@ -0,0 +1,224 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/**
+* Contains the span API.
+**/
+
+// Kind - the span kind
+type Kind string
+
+const (
+	// KindInternal - an internal operation
+	KindInternal Kind = "internal"
+
+	// KindServer - a server side request handling
+	KindServer Kind = "server"
+
+	// KindClient - a call to a remote service
+	KindClient Kind = "client"
+)
+
+const (
+	// HeaderTraceparent - the W3C traceparent header
+	HeaderTraceparent = "traceparent"
+
+	// HeaderTracestate - the W3C tracestate header
+	HeaderTracestate = "tracestate"
+)
+
+type contextKeyType int
+
+const (
+	spanKey contextKeyType = iota
+	remoteKey
+)
+
+// Span - a timed operation of a trace
+type Span struct {
+	mutex      sync.Mutex
+	Name       string
+	Kind       Kind
+	Context    SpanContext
+	ParentID   SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Error      string
+	finished   bool
+}
+
+// SetAttribute - sets a span attribute
+func (s *Span) SetAttribute(key string, value interface{}) {
+
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = map[string]interface{}{}
+	}
+
+	s.Attributes[key] = value
+}
+
+// SetError - marks the span as failed (nil errors are ignored)
+func (s *Span) SetError(err error) {
+
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Error = err.Error()
+}
+
+// Finish - ends the span and sends it to the exporters
+func (s *Span) Finish() {
+
+	s.FinishAt(time.Now())
+}
+
+// FinishWithError - sets the error (if any) and ends the span
+func (s *Span) FinishWithError(err error) {
+
+	s.SetError(err)
+	s.Finish()
+}
+
+// FinishAt - ends the span using the given time and sends it to the exporters
+func (s *Span) FinishAt(end time.Time) {
+
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.finished {
+		s.mutex.Unlock()
+		return
+	}
+	s.finished = true
+	s.End = end
+	s.mutex.Unlock()
+
+	if s.Context.Sampled() {
+		export(s)
+	}
+}
+
+// Start - starts a new internal span as child of the span (or remote span context) found in the context
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+
+	return StartAt(ctx, name, KindInternal, time.Now())
+}
+
+// StartKind - same as Start using the given span kind
+func StartKind(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+
+	return StartAt(ctx, name, kind, time.Now())
+}
+
+// StartAt - starts a new span at the given time as child of the span (or remote span context) found in the context
+func StartAt(ctx context.Context, name string, kind Kind, start time.Time) (context.Context, *Span) {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &Span{
+		Name:  name,
+		Kind:  kind,
+		Start: start,
+	}
+
+	parent, ok := parentContext(ctx)
+	if ok {
+		span.Context = SpanContext{
+			TraceID:    parent.TraceID,
+			SpanID:     newSpanID(),
+			Flags:      parent.Flags,
+			TraceState: parent.TraceState,
+		}
+		span.ParentID = parent.SpanID
+	} else {
+		span.Context = SpanContext{
+			TraceID: newTraceID(),
+			SpanID:  newSpanID(),
+			Flags:   flagSampled,
+		}
+	}
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// parentContext - returns the span context of the current span or the remote one
+func parentContext(ctx context.Context) (SpanContext, bool) {
+
+	if span := FromContext(ctx); span != nil {
+		return span.Context, true
+	}
+
+	if sc, ok := ctx.Value(remoteKey).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+
+	return SpanContext{}, false
+}
+
+// FromContext - returns the current span or nil
+func FromContext(ctx context.Context) *Span {
+
+	if ctx == nil {
+		return nil
+	}
+
+	span, _ := ctx.Value(spanKey).(*Span)
+
+	return span
+}
+
+// ContextWithRemote - returns a copy of the context carrying a span context received from a remote caller
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// Extract - reads the trace context from the request headers into the context (invalid headers are ignored)
+func Extract(ctx context.Context, header http.Header) context.Context {
+
+	sc, err := ParseTraceparent(header.Get(HeaderTraceparent), header.Get(HeaderTracestate))
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithRemote(ctx, sc)
+}
+
+// Inject - writes the trace context of the current span into the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+
+	sc, ok := parentContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(HeaderTraceparent, sc.Traceparent())
+
+	if sc.TraceState != "" {
+		header.Set(HeaderTracestate, sc.TraceState)
+	} else {
+		header.Del(HeaderTracestate)
+	}
+}
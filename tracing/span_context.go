@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+/**
+* Contains the W3C Trace Context identifiers and propagation format.
+**/
+
+const (
+	traceparentVersion = "00"
+	flagSampled        = 0x01
+	maxTracestateSize  = 512
+)
+
+// TraceID - the 16 bytes trace identifier
+type TraceID [16]byte
+
+// SpanID - the 8 bytes span identifier
+type SpanID [8]byte
+
+// IsValid - checks if the id is not all zeros
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String - returns the lowercase hex representation
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid - checks if the id is not all zeros
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String - returns the lowercase hex representation
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext - the propagated part of a span
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	Remote     bool
+}
+
+// IsValid - checks if both identifiers are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Sampled - checks if the sampled flag is set
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&flagSampled == flagSampled
+}
+
+// Traceparent - returns the traceparent header value
+func (sc SpanContext) Traceparent() string {
+
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+var (
+	// ErrInvalidTraceparent - raised when the traceparent header can not be parsed
+	ErrInvalidTraceparent = errors.New("invalid traceparent")
+)
+
+// ParseTraceparent - parses the traceparent and tracestate header values
+func ParseTraceparent(traceparent, tracestate string) (SpanContext, error) {
+
+	sc := SpanContext{}
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, ErrInvalidTraceparent
+	}
+
+	if parts[0] == traceparentVersion && len(parts) != 4 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	if !decodeLowerHex(parts[0], nil) ||
+		!decodeLowerHex(parts[1], sc.TraceID[:]) ||
+		!decodeLowerHex(parts[2], sc.SpanID[:]) {
+		return sc, ErrInvalidTraceparent
+	}
+
+	flags := [1]byte{}
+	if !decodeLowerHex(parts[3], flags[:]) {
+		return sc, ErrInvalidTraceparent
+	}
+
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	if len(tracestate) <= maxTracestateSize {
+		sc.TraceState = strings.TrimSpace(tracestate)
+	}
+
+	sc.Remote = true
+
+	return sc, nil
+}
+
+// decodeLowerHex - decodes a lowercase hex string to the destination (only validates if the destination is nil)
+func decodeLowerHex(s string, dst []byte) bool {
+
+	if dst != nil && len(s) != len(dst)*2 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	if dst != nil {
+		_, err := hex.Decode(dst, []byte(s))
+		return err == nil
+	}
+
+	return true
+}
+
+// newTraceID - generates a random trace id
+func newTraceID() TraceID {
+
+	t := TraceID{}
+	for !t.IsValid() {
+		if _, err := rand.Read(t[:]); err != nil {
+			panic(err)
+		}
+	}
+
+	return t
+}
+
+// newSpanID - generates a random span id
+func newSpanID() SpanID {
+
+	s := SpanID{}
+	for !s.IsValid() {
+		if _, err := rand.Read(s[:]); err != nil {
+			panic(err)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+
+	sc, err := ParseTraceparent(validTraceparent, "congo=t61rcWkgMzE")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled())
+	assert.True(t, sc.Remote)
+	assert.Equal(t, "congo=t61rcWkgMzE", sc.TraceState)
+	assert.Equal(t, validTraceparent, sc.Traceparent())
+}
+
+func TestParseInvalidTraceparent(t *testing.T) {
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+
+	for _, traceparent := range invalid {
+		_, err := ParseTraceparent(traceparent, "")
+		assert.Equal(t, ErrInvalidTraceparent, err, traceparent)
+	}
+}
+
+func TestSpanPropagation(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	Configure(NewJSONExporter(buffer))
+	defer Configure()
+
+	header := http.Header{}
+	header.Set(HeaderTraceparent, validTraceparent)
+
+	ctx, parent := StartKind(Extract(context.Background(), header), "parent", KindServer)
+	_, child := Start(ctx, "child")
+
+	assert.Equal(t, parent.Context.TraceID, child.Context.TraceID)
+	assert.Equal(t, parent.Context.SpanID, child.ParentID)
+	assert.Equal(t, "00f067aa0ba902b7", parent.ParentID.String())
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	assert.Equal(t, parent.Context.Traceparent(), outgoing.Get(HeaderTraceparent))
+
+	child.Finish()
+
+	exported := map[string]interface{}{}
+	if !assert.NoError(t, json.Unmarshal(buffer.Bytes(), &exported)) {
+		return
+	}
+
+	assert.Equal(t, "child", exported["name"])
+	assert.Equal(t, parent.Context.SpanID.String(), exported["parentId"])
+}